@@ -0,0 +1,87 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"jira-export/pkg/jira"
+	"jira-export/pkg/logger"
+	"jira-export/pkg/output"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var agileProject string
+
+func init() {
+	ExportAgileCmd.Flags().StringVar(&agileProject, "project", "", "Only export the boards of this project key or ID (default all boards)")
+	RootCmd.AddCommand(ExportAgileCmd)
+}
+
+var ExportAgileCmd = &cobra.Command{
+	Use:   "export-agile",
+	Short: "Export the agile boards and their sprints to boards.csv and sprints.csv",
+	Run: func(cmd *cobra.Command, args []string) {
+		jiraAPI := mustJiraAPI()
+
+		ctx, cancel := runContext(cmd)
+		defer cancel()
+
+		if err := exportAgile(ctx, jiraAPI, agileProject, outputDir); err != nil {
+			os.Exit(handleError("Agile export failed", err))
+		}
+	},
+}
+
+// exportAgile writes the boards of the project and the sprints of its scrum
+// boards to boards.csv, sprints.csv and agile.json
+func exportAgile(ctx context.Context, jiraAPI jira.JiraAPI, project string, outputDir string) error {
+	boards, err := jiraAPI.GetBoards(ctx, project)
+	if err != nil {
+		return err
+	}
+
+	sprints, err := jiraAPI.GetSprints(ctx, boards)
+	if err != nil {
+		return err
+	}
+
+	logger.Logger.Info("Exported boards", "boards", len(boards), "sprints", len(sprints))
+
+	data := map[string]any{"boards": boards, "sprints": sprints}
+	if err := output.WriteJSON(fmt.Sprintf("%s/agile.json", outputDir), data); err != nil {
+		return fmt.Errorf("error storing agile json: %v", err)
+	}
+
+	if err := jira.WriteBoardsCSV(fmt.Sprintf("%s/boards.csv", outputDir), boards); err != nil {
+		return fmt.Errorf("error writing boards csv: %v", err)
+	}
+
+	if err := sprints.WriteCSV(fmt.Sprintf("%s/sprints.csv", outputDir)); err != nil {
+		return fmt.Errorf("error writing sprints csv: %v", err)
+	}
+
+	return nil
+}
+
+// exportSprints writes the sprints of the issues to issue-sprint-details.csv
+// and the sprint membership of each issue to issue-sprints.csv. The file names
+// differ from sprints.csv of export-agile, so both exports can share a directory.
+func exportSprints(issues []jira.SearchIssue, sprintFieldIDs []string, outputDir string) error {
+	memberships, sprints, err := jira.IssueSprintMemberships(issues, sprintFieldIDs)
+	if err != nil {
+		return fmt.Errorf("error reading sprints: %v", err)
+	}
+
+	logger.Logger.Info("Exported sprints", "sprints", len(sprints), "memberships", len(memberships))
+
+	if err := sprints.WriteCSV(fmt.Sprintf("%s/issue-sprint-details.csv", outputDir)); err != nil {
+		return fmt.Errorf("error writing sprints csv: %v", err)
+	}
+
+	if err := memberships.WriteCSV(fmt.Sprintf("%s/issue-sprints.csv", outputDir)); err != nil {
+		return fmt.Errorf("error writing issue sprints csv: %v", err)
+	}
+
+	return nil
+}
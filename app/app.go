@@ -2,15 +2,20 @@ package app
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"jira-export/pkg/jira"
 	"jira-export/pkg/logger"
 	"jira-export/pkg/output"
+	rutil "jira-export/pkg/request_util"
 	"jira-export/pkg/secrets"
 	"os"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/spf13/cobra"
@@ -24,6 +29,44 @@ var (
 	jql        string
 	outputDir  string
 	maxResults int
+	timeout    time.Duration
+	legacy     bool
+	authMode   string
+	flavor     string
+
+	runTimeout   time.Duration
+	flushPartial bool
+	rateLimit    float64
+	maxAttempts  int
+	concurrency  int
+	limit        int
+	fields       []string
+	expand       []string
+	filter       string
+
+	skipValidation bool
+	strict         bool
+	lenient        bool
+	decodeMode     string
+	timezone       string
+	dateFormat     string
+
+	withChangelog bool
+	withComments  bool
+	withWorklogs  bool
+	worklogsSince string
+	timesheetBy   string
+
+	withSprints bool
+
+	withUsers     bool
+	resolveGroups bool
+
+	jsm bool
+
+	withAttachments     bool
+	attachmentMaxSize   int64
+	attachmentMimeTypes []string
 )
 
 const (
@@ -42,16 +85,52 @@ func init() {
 	viper.BindEnv("token")
 	viper.BindEnv("url")
 	viper.BindEnv("jql")
+	viper.BindEnv("legacy_search")
+	viper.BindEnv("auth_mode")
+	viper.BindEnv("flavor")
+	viper.BindEnv("filter")
 
 	// Bind flags
 	RootCmd.PersistentFlags().StringVarP(&username, "username", "u", viper.GetString("username"), "Jira username")
 	RootCmd.PersistentFlags().StringVarP(&token, "token", "t", viper.GetString("token"), "Jira token")
 	RootCmd.PersistentFlags().StringVarP(&url, "url", "r", viper.GetString("url"), "Jira URL")
+	RootCmd.PersistentFlags().StringVar(&authMode, "auth-mode", viper.GetString("auth_mode"), "Authentication mode: basic (username and API token) or bearer (personal access token)")
+	RootCmd.PersistentFlags().StringVar(&flavor, "flavor", viper.GetString("flavor"), "Jira flavor: cloud (REST API v3) or server (Server / Data Center, REST API v2)")
 	RootCmd.PersistentFlags().StringVarP(&jql, "jql", "j", viper.GetString("jql"), "JQL query")
 	// Trim surrounding single quotes if present
 	jql = strings.Trim(jql, "'")
+	RootCmd.PersistentFlags().StringVar(&filter, "filter", viper.GetString("filter"), "ID or name of a saved Jira filter to export instead of the JQL query")
+	RootCmd.PersistentFlags().BoolVar(&skipValidation, "skip-validation", false, "Do not validate the JQL query before the export")
+	RootCmd.PersistentFlags().BoolVar(&strict, "strict", false, "Fail if an issue cannot be converted")
+	RootCmd.PersistentFlags().BoolVar(&lenient, "lenient", true, "Write the issues which cannot be converted to rejects.ndjson and export the rest")
+	RootCmd.PersistentFlags().StringVar(&decodeMode, "decode-mode", string(jira.DecodeLenient), "Handling of issues which cannot be converted: lenient or strict, same as --lenient and --strict")
+	RootCmd.MarkFlagsMutuallyExclusive("strict", "lenient", "decode-mode")
+	RootCmd.PersistentFlags().StringVar(&timezone, "timezone", "", "Convert the issue timestamps to this time zone, e.g. UTC, Local or Europe/Berlin (default the offsets returned by Jira)")
+	RootCmd.PersistentFlags().StringVar(&dateFormat, "date-format", jira.DateFormatJira, "Format of the timestamps in the CSV file: jira, rfc3339, date, datetime or a Go time layout")
 	RootCmd.PersistentFlags().StringVarP(&outputDir, "output", "o", "dist/jira/results", "Output directory")
-	RootCmd.PersistentFlags().IntVarP(&maxResults, "max-results", "m", 100, "Max results")
+	RootCmd.PersistentFlags().IntVarP(&maxResults, "max-results", "m", 100, "Max results per page (page size)")
+	RootCmd.PersistentFlags().IntVar(&limit, "limit", 0, "Maximum number of exported issues (0 means no limit)")
+	RootCmd.PersistentFlags().StringSliceVar(&fields, "fields", nil, "Comma separated field IDs or names to export (default all navigable fields)")
+	RootCmd.PersistentFlags().StringSliceVar(&expand, "expand", nil, "Comma separated entities to expand in the search results, e.g. renderedFields")
+	RootCmd.PersistentFlags().BoolVar(&withChangelog, "with-changelog", false, "Export the changelog of the issues to changelog.csv and changelog.json")
+	RootCmd.PersistentFlags().BoolVar(&withComments, "with-comments", false, "Export the comments of the issues to comments.csv and comments.json")
+	RootCmd.PersistentFlags().BoolVar(&withWorklogs, "with-worklogs", false, "Export the worklogs of the issues to worklogs.csv, worklogs.json and timesheet.csv")
+	RootCmd.PersistentFlags().StringVar(&worklogsSince, "worklogs-since", "", "Only fetch the worklogs updated since this date (YYYY-MM-DD or RFC 3339) using the incremental worklog API")
+	RootCmd.PersistentFlags().StringVar(&timesheetBy, "timesheet-by", jira.TimesheetByEpic, "Group the timesheet by epic or component")
+	RootCmd.PersistentFlags().BoolVar(&withSprints, "with-sprints", false, "Export the sprints of the issues to issue-sprint-details.csv and issue-sprints.csv")
+	RootCmd.PersistentFlags().BoolVar(&withUsers, "with-users", false, "Export the users referenced by the issues, user picker fields and the exported changelogs, comments, worklogs and service requests to users.csv and users.json")
+	RootCmd.PersistentFlags().BoolVar(&resolveGroups, "resolve-groups", false, "Export the group memberships of the referenced users to user-groups.csv (implies --with-users)")
+	RootCmd.PersistentFlags().BoolVar(&jsm, "jsm", false, "Export the Jira Service Management request types, participants, organizations and SLAs to jsm-requests.csv, jsm-slas.csv and jsm.json")
+	RootCmd.PersistentFlags().BoolVar(&withAttachments, "with-attachments", false, "Download the attachments of the issues into <output>/attachments/<KEY>/")
+	RootCmd.PersistentFlags().Int64Var(&attachmentMaxSize, "attachment-max-size", 0, "Maximum size of downloaded attachments in bytes (0 means no limit)")
+	RootCmd.PersistentFlags().StringSliceVar(&attachmentMimeTypes, "attachment-types", nil, "Comma separated MIME types of downloaded attachments, e.g. image/*,application/pdf (default all)")
+	RootCmd.PersistentFlags().IntVar(&concurrency, "concurrency", jira.DefaultConcurrency, "Number of parallel requests")
+	RootCmd.PersistentFlags().BoolVar(&legacy, "legacy-search", viper.GetBool("legacy_search"), "Use the deprecated startAt based search endpoint")
+	RootCmd.PersistentFlags().DurationVar(&timeout, "timeout", rutil.DefaultTransportConfig().Timeout, "Timeout of a single HTTP request")
+	RootCmd.PersistentFlags().DurationVar(&runTimeout, "run-timeout", 0, "Timeout of the whole export run (0 means no timeout)")
+	RootCmd.PersistentFlags().Float64Var(&rateLimit, "rate-limit", 10, "Maximum number of requests per second (0 means no limit)")
+	RootCmd.PersistentFlags().IntVar(&maxAttempts, "max-attempts", jira.DefaultRetryPolicy().MaxAttempts, "Maximum number of attempts per request (0 means no limit)")
+	RootCmd.PersistentFlags().BoolVar(&flushPartial, "flush-partial", false, "Write the issues fetched so far if the export is interrupted")
 }
 
 var RootCmd = &cobra.Command{
@@ -59,58 +138,316 @@ var RootCmd = &cobra.Command{
 	Short: "Export Jira issues to CSV and JSON",
 	Long:  `Export Jira issues to CSV and JSON`,
 	Run: func(cmd *cobra.Command, args []string) {
-		if username == "" {
-			logger.Logger.Error("Missing username")
+		if jql == "" && filter == "" {
+			logger.Logger.Error("Missing JQL query or filter")
 			os.Exit(1)
 		}
-
-		if token == "" {
-			logger.Logger.Error("Missing token")
+		if filter != "" && cmd.Flags().Changed("jql") {
+			logger.Logger.Error("Both --jql and a filter are given, use either of them")
 			os.Exit(1)
 		}
 
-		if url == "" {
-			logger.Logger.Error("Missing URL")
-			os.Exit(1)
+		secrets, opts := mustAPIConfig()
+
+		ctx, cancel := runContext(cmd)
+		defer cancel()
+
+		// Use the JQL query of the saved filter
+		if filter != "" {
+			f, err := jira.NewJiraAPI(secrets, maxResults, opts...).ResolveFilter(ctx, filter)
+			if err != nil {
+				os.Exit(handleError("Resolving filter failed", err, "filter", filter))
+			}
+			logger.Logger.Info("Exporting filter", "id", f.ID, "name", f.Name, "jql", f.JQL)
+			if jql != "" {
+				logger.Logger.Info("Ignoring the JQL query of JIRA_EXPORT_JQL in favour of the filter", "jql", jql)
+			}
+			jql = f.JQL
 		}
 
-		if jql == "" {
-			logger.Logger.Error("Missing JQL query")
+		mode, err := decodeModeFromFlags(cmd)
+		if err != nil {
+			logger.Logger.Error("Invalid decode mode", "error", err)
 			os.Exit(1)
 		}
 
-		secrets := secrets.Secrets{
-			Username: username,
-			Token:    token,
-			URL:      url,
+		var location *time.Location
+		if timezone != "" {
+			location, err = time.LoadLocation(timezone)
+			if err != nil {
+				logger.Logger.Error("Invalid time zone", "timezone", timezone, "error", err)
+				os.Exit(1)
+			}
 		}
 
-		err := Export(jql, outputDir, secrets, maxResults)
+		options := ExportOptions{
+			OutputDir:    outputDir,
+			MaxResults:   maxResults,
+			FlushPartial: flushPartial,
+			Fields:       fields,
+			Expand:       expand,
+
+			SkipValidation: skipValidation,
+			DecodeMode:     mode,
+			Location:       location,
+			DateFormat:     dateFormat,
+
+			WithChangelog: withChangelog,
+			WithComments:  withComments,
+			WithWorklogs:  withWorklogs,
+			WorklogsSince: worklogsSince,
+			TimesheetBy:   timesheetBy,
+			WithSprints:   withSprints,
+			WithUsers:     withUsers || resolveGroups,
+			ResolveGroups: resolveGroups,
+			JSM:           jsm,
+
+			WithAttachments: withAttachments,
+			AttachmentFilter: jira.AttachmentFilter{
+				MaxSize:   attachmentMaxSize,
+				MimeTypes: attachmentMimeTypes,
+			},
+		}
+
+		err = Export(ctx, jql, secrets, options, opts...)
 		if err != nil {
-			logger.Logger.Error("Export failed", "error", err)
+			os.Exit(handleError("Export failed", err, "jql", jql))
 		}
 
 	},
 }
 
-func Export(jqlQuery string, outputDir string, secrets secrets.Secrets, maxResults int) error {
+// decodeModeFromFlags returns the decode mode given by --strict, --lenient or
+// --decode-mode. Only one of them can be set, lenient is the default.
+func decodeModeFromFlags(cmd *cobra.Command) (jira.DecodeMode, error) {
+	switch {
+	case cmd.Flags().Changed("strict") && strict, cmd.Flags().Changed("lenient") && !lenient:
+		return jira.DecodeStrict, nil
+	case cmd.Flags().Changed("strict"), cmd.Flags().Changed("lenient"):
+		return jira.DecodeLenient, nil
+	}
+	return jira.ParseDecodeMode(decodeMode)
+}
+
+// mustAPIConfig returns the credentials and the JiraAPI options given by the
+// flags and environment variables. It exits if they are invalid.
+func mustAPIConfig() (secrets.Secrets, []jira.Option) {
+	secrets := secrets.Secrets{
+		Username: username,
+		Token:    token,
+		URL:      url,
+		AuthMode: authMode,
+	}
+	if err := secrets.Validate(); err != nil {
+		logger.Logger.Error("Invalid credentials", "error", err)
+		os.Exit(1)
+	}
+
+	apiFlavor, err := jira.ParseAPIFlavor(flavor)
+	if err != nil {
+		logger.Logger.Error("Invalid flavor", "error", err)
+		os.Exit(1)
+	}
+
+	configureRateLimiter()
+
+	retryPolicy := jira.DefaultRetryPolicy()
+	retryPolicy.MaxAttempts = maxAttempts
+
+	// The default client caps every request at 2 minutes, so the
+	// transport has to allow requests as long as the request timeout
+	transportConfig := rutil.DefaultTransportConfig()
+	transportConfig.Timeout = timeout
+	transportConfig.ResponseHeaderTimeout = timeout
+
+	opts := []jira.Option{
+		jira.WithTransportConfig(transportConfig),
+		jira.WithLegacySearch(legacy),
+		jira.WithFlavor(apiFlavor),
+		jira.WithRequestTimeout(timeout),
+		jira.WithRateLimiter(rutil.DefaultRateLimiter),
+		jira.WithRetryPolicy(retryPolicy),
+		jira.WithConcurrency(concurrency),
+		jira.WithLimit(limit),
+	}
+	return secrets, opts
+}
+
+// configureRateLimiter sets the rate of the process-wide limiter from the flags
+// once, so all JiraAPI objects share its pacing and slow-down state
+var configureRateLimiter = sync.OnceFunc(func() {
+	rutil.DefaultRateLimiter.SetLimit(rateLimit, int(rateLimit)+1)
+})
+
+// mustJiraAPI creates a JiraAPI object from the flags. It exits if they are invalid.
+func mustJiraAPI() jira.JiraAPI {
+	secrets, opts := mustAPIConfig()
+	return jira.NewJiraAPI(secrets, maxResults, opts...)
+}
+
+// runContext returns the context of the command bound to the run timeout
+func runContext(cmd *cobra.Command) (context.Context, context.CancelFunc) {
+	if runTimeout > 0 {
+		return context.WithTimeout(cmd.Context(), runTimeout)
+	}
+	return context.WithCancel(cmd.Context())
+}
+
+// ExportOptions contains the settings of an export run
+type ExportOptions struct {
+	OutputDir  string
+	MaxResults int
+	// FlushPartial writes the issues fetched so far if the context
+	// is cancelled. The output files are marked with a ".partial" suffix.
+	FlushPartial bool
+	// Fields are the IDs or names of the exported fields. All navigable fields are exported if empty.
+	Fields []string
+	// Expand are the entities expanded in the search results
+	Expand []string
+	// SkipValidation does not validate the JQL query before the search
+	SkipValidation bool
+	// DecodeMode selects whether issues which cannot be converted fail the
+	// export or are written to rejects.ndjson. The zero value is lenient.
+	DecodeMode jira.DecodeMode
+	// Location is the time zone of the issue timestamps. The offsets returned by Jira are kept if nil.
+	Location *time.Location
+	// DateFormat is the format of the timestamps in the CSV file, see jira.DateLayout
+	DateFormat string
+
+	// WithChangelog exports the field changes of the issues
+	WithChangelog bool
+	// WithComments exports the comments of the issues
+	WithComments bool
+	// WithWorklogs exports the worklogs of the issues and a timesheet
+	WithWorklogs bool
+	// WorklogsSince fetches the worklogs updated since this date incrementally
+	WorklogsSince string
+	// TimesheetBy groups the timesheet by "epic" or "component"
+	TimesheetBy string
+	// WithSprints exports the sprint history of the issues
+	WithSprints bool
+	// WithUsers exports the users referenced by the issues and by the
+	// exported changelogs, comments, worklogs and service requests
+	WithUsers bool
+	// ResolveGroups exports the group memberships of the users
+	ResolveGroups bool
+	// JSM exports the service desk data of the issues
+	JSM bool
+
+	// WithAttachments downloads the attachments matching the AttachmentFilter
+	WithAttachments  bool
+	AttachmentFilter jira.AttachmentFilter
+}
+
+func Export(ctx context.Context, jqlQuery string, secrets secrets.Secrets, options ExportOptions, opts ...jira.Option) error {
 
 	// Create a JiraAPI object
-	jiraAPI := jira.NewJiraAPI(secrets, maxResults)
+	jiraAPI := jira.NewJiraAPI(secrets, options.MaxResults, opts...)
 	data := jira.JiraSearchResults{}
+	outputDir := options.OutputDir
 	outputFileName := "jira-export"
 
 	logger.Logger.Debug("Exporting Jira issues", "jql", jqlQuery)
 
-	data, err := jiraAPI.GetFilterResults(jqlQuery)
+	if options.WithWorklogs && options.TimesheetBy != jira.TimesheetByEpic && options.TimesheetBy != jira.TimesheetByComponent {
+		return fmt.Errorf("invalid timesheet grouping %q, expected %s or %s", options.TimesheetBy, jira.TimesheetByEpic, jira.TimesheetByComponent)
+	}
+
+	// Fail fast on an invalid query
+	if !options.SkipValidation {
+		if err := mustBeValidJQL(ctx, jiraAPI, jqlQuery); err != nil {
+			return fmt.Errorf("error validating JQL: %w", err)
+		}
+	}
+
+	// Translate the field names to IDs
+	if len(options.Fields) > 0 {
+		ids, err := jiraAPI.ResolveFieldIDs(ctx, options.Fields)
+		if err != nil {
+			return fmt.Errorf("error resolving fields: %w", err)
+		}
+		jiraAPI.Fields = ids
+
+		// The attachments are read from the attachment field
+		if options.WithAttachments && jira.IsFieldSubset(jiraAPI.Fields) {
+			jiraAPI.Fields = append(jiraAPI.Fields, "attachment")
+		}
+	}
+
+	// The sprints and organizations are read from custom fields
+	var sprintFieldIDs, organizationFieldIDs []string
+	if options.WithSprints || options.JSM {
+		fieldMap, err := jiraAPI.GetFieldMap(ctx)
+		if err != nil {
+			return fmt.Errorf("error getting custom fields: %w", err)
+		}
+		if options.WithSprints {
+			sprintFieldIDs = fieldMap.FieldsBySchema(jira.SprintFieldSchema)
+			if len(sprintFieldIDs) == 0 {
+				logger.Logger.Warn("No sprint field found, the issues have no sprints")
+			}
+		}
+		if options.JSM {
+			organizationFieldIDs = fieldMap.FieldsBySchema(jira.OrganizationsFieldSchema)
+		}
+		if jira.IsFieldSubset(jiraAPI.Fields) {
+			jiraAPI.Fields = append(jiraAPI.Fields, sprintFieldIDs...)
+			jiraAPI.Fields = append(jiraAPI.Fields, organizationFieldIDs...)
+		}
+	}
+	jiraAPI.Expand = append(jiraAPI.Expand, options.Expand...)
+	if options.WithChangelog {
+		jiraAPI.Expand = append(jiraAPI.Expand, "changelog")
+	}
+
+	data, err := jiraAPI.GetFilterResults(ctx, jqlQuery)
+	logger.Logger.Info("Time spent throttled by rate limiting", "throttled", jiraAPI.ThrottledTime())
 	if err != nil {
-		return fmt.Errorf("error getting filter results: %v", err)
+		// Only flush the results if the export was interrupted
+		if ctx.Err() == nil || !options.FlushPartial || len(data.Issues) == 0 {
+			return fmt.Errorf("error getting filter results: %w", err)
+		}
+		logger.Logger.Warn("Export interrupted, writing partial results", "error", err, "count", len(data.Issues))
+		outputFileName += ".partial"
 	}
 
-	issues, err := data.IssuesToJiraIssues()
+	// The rejected issues are left out of every export
+	decodeMode := options.DecodeMode
+	if decodeMode == "" {
+		decodeMode = jira.DecodeLenient
+	}
+	issues, accepted, rejects, err := data.ConvertIssues(decodeMode)
 	if err != nil {
 		return fmt.Errorf("error converting issues: %v", err)
 	}
+	rejectsFile := fmt.Sprintf("%s/rejects.ndjson", outputDir)
+	if len(rejects) > 0 {
+		logger.Logger.Warn("Some issues could not be converted", "rejected", len(rejects))
+		if err := rejects.WriteNDJSON(rejectsFile); err != nil {
+			return fmt.Errorf("error writing rejects: %v", err)
+		}
+	} else if err := os.Remove(rejectsFile); err != nil && !os.IsNotExist(err) {
+		// Remove the rejects of a previous run
+		return fmt.Errorf("error removing rejects: %v", err)
+	}
+
+	// Use the field names for the custom fields. Without them the epics are
+	// still inherited from the parents, only the Epic Link fields are unknown.
+	var epicLinkFieldIDs []string
+	fieldMap, err := jiraAPI.GetFieldMap(ctx)
+	if err != nil {
+		logger.Logger.Warn("Could not get the field names, keeping the custom field IDs", "error", err)
+	} else {
+		epicLinkFieldIDs = fieldMap.FieldsBySchema(jira.EpicLinkFieldSchema)
+	}
+	if missingParents := issues.ResolveEpics(epicLinkFieldIDs); len(missingParents) > 0 {
+		logger.Logger.Warn("Some parents are not part of the export, their children have no epic", "parents", len(missingParents), "hint", "include the parents in the JQL query")
+		logger.Logger.Debug("Parents not part of the export", "keys", missingParents)
+	}
+	if err == nil {
+		issues.NameCustomFields(fieldMap)
+	}
+	issues.In(options.Location)
 
 	logger.Logger.Info("Exported Jira issues", "count", len(issues))
 
@@ -129,10 +466,112 @@ func Export(jqlQuery string, outputDir string, secrets secrets.Secrets, maxResul
 
 	// Write the issues to a csv file
 	csvFile := fmt.Sprintf("%s/%s.csv", outputDir, outputFileName)
-	err = issues.WriteCSV(csvFile)
+	err = issues.WriteCSVWithOptions(csvFile, jira.CSVOptions{Fields: jiraAPI.Fields, DateFormat: options.DateFormat})
 	if err != nil {
 		return fmt.Errorf("error writing csv: %v", err)
 	}
 
+	// Write the issue links, parents and subtasks as an edge list
+	linksFile := fmt.Sprintf("%s/%s-links.csv", outputDir, outputFileName)
+	if err := issues.Links().WriteCSV(linksFile); err != nil {
+		return fmt.Errorf("error writing links csv: %v", err)
+	}
+
+	// The users are collected from every exported entity
+	userRefs := [][]jira.JiraIssueUser{issues.ReferencedUsers()}
+
+	if options.WithChangelog {
+		users, err := exportChangelog(ctx, jiraAPI, accepted, outputDir)
+		if err != nil {
+			return err
+		}
+		userRefs = append(userRefs, users)
+	}
+
+	if options.WithComments {
+		users, err := exportComments(ctx, jiraAPI, issues, outputDir)
+		if err != nil {
+			return err
+		}
+		userRefs = append(userRefs, users)
+	}
+
+	if options.WithWorklogs {
+		users, err := exportWorklogs(ctx, jiraAPI, issues, options.WorklogsSince, options.TimesheetBy, outputDir)
+		if err != nil {
+			return err
+		}
+		userRefs = append(userRefs, users)
+	}
+
+	if options.WithSprints {
+		if err := exportSprints(accepted, sprintFieldIDs, outputDir); err != nil {
+			return err
+		}
+	}
+
+	if options.JSM {
+		users, err := exportServiceRequests(ctx, jiraAPI, accepted, organizationFieldIDs, outputDir)
+		if err != nil {
+			return err
+		}
+		userRefs = append(userRefs, users)
+	}
+
+	if options.WithUsers {
+		if err := exportUsers(ctx, jiraAPI, jira.UniqueUsers(userRefs...), options.ResolveGroups, outputDir); err != nil {
+			return err
+		}
+	}
+
+	if options.WithAttachments {
+		if err := exportAttachments(ctx, jiraAPI, accepted, options.AttachmentFilter, outputDir); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
+
+// handleError logs the error with a hint matching the error type and returns the exit code.
+// The key-value pairs, e.g. the JQL query, are added to the log message.
+func handleError(msg string, err error, keyvals ...any) int {
+	logError := func(kv ...any) {
+		logger.Logger.Error(msg, append(kv, keyvals...)...)
+	}
+
+	var apiErr *jira.APIError
+	errors.As(err, &apiErr)
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		logError("error", "interrupted")
+		return 130
+	case errors.Is(err, context.DeadlineExceeded):
+		logError("error", err, "hint", "the run or request timeout was exceeded")
+		return 1
+	case errors.Is(err, jira.ErrUnauthorized):
+		logError("error", err, "hint", "check the username, token and auth mode")
+		return 2
+	case errors.Is(err, jira.ErrForbidden):
+		logError("error", err, "hint", "the user lacks the permission for this resource")
+		return 2
+	case errors.Is(err, jira.ErrInvalidJQL):
+		logError("error", "invalid JQL query")
+		if apiErr != nil {
+			for _, m := range apiErr.Messages() {
+				logger.Logger.Error("JQL error", "message", m)
+			}
+			for _, m := range apiErr.WarningMessages {
+				logger.Logger.Warn("JQL warning", "message", m)
+			}
+		}
+		return 3
+	case errors.Is(err, jira.ErrRateLimited):
+		logError("error", err, "hint", "lower --rate-limit or raise --max-attempts")
+		return 4
+	}
+
+	logError("error", err)
+	return 1
+}
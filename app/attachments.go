@@ -0,0 +1,31 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"jira-export/pkg/jira"
+	"jira-export/pkg/logger"
+)
+
+// exportAttachments downloads the attachments of the issues into
+// <output>/attachments/<KEY>/ and writes the manifest
+func exportAttachments(ctx context.Context, jiraAPI jira.JiraAPI, issues []jira.SearchIssue, filter jira.AttachmentFilter, outputDir string) error {
+	attachments := jira.IssueAttachments(issues)
+	manifest, err := jiraAPI.DownloadAttachments(ctx, attachments, fmt.Sprintf("%s/attachments", outputDir), filter)
+	if err != nil {
+		return fmt.Errorf("error downloading attachments: %w", err)
+	}
+
+	counts := map[string]int{}
+	for _, e := range manifest {
+		counts[e.Status]++
+	}
+	logger.Logger.Info("Exported attachments",
+		"count", len(manifest),
+		jira.AttachmentDownloaded, counts[jira.AttachmentDownloaded],
+		jira.AttachmentUnchanged, counts[jira.AttachmentUnchanged],
+		jira.AttachmentFiltered, counts[jira.AttachmentFiltered],
+	)
+
+	return nil
+}
@@ -0,0 +1,35 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"jira-export/pkg/jira"
+	"jira-export/pkg/logger"
+	"jira-export/pkg/output"
+)
+
+// exportChangelog writes the field changes of the issues to changelog.csv
+// and changelog.json and the status changes to status-transitions.csv.
+// It returns the authors of the changes.
+func exportChangelog(ctx context.Context, jiraAPI jira.JiraAPI, issues []jira.SearchIssue, outputDir string) ([]jira.JiraIssueUser, error) {
+	entries, err := jiraAPI.GetChangelogs(ctx, issues)
+	if err != nil {
+		return nil, fmt.Errorf("error getting changelogs: %w", err)
+	}
+
+	logger.Logger.Info("Exported changelog", "changes", len(entries))
+
+	if err := output.WriteJSON(fmt.Sprintf("%s/changelog.json", outputDir), entries); err != nil {
+		return nil, fmt.Errorf("error storing changelog json: %v", err)
+	}
+
+	if err := entries.WriteCSV(fmt.Sprintf("%s/changelog.csv", outputDir)); err != nil {
+		return nil, fmt.Errorf("error writing changelog csv: %v", err)
+	}
+
+	if err := entries.WriteTransitionsCSV(fmt.Sprintf("%s/status-transitions.csv", outputDir)); err != nil {
+		return nil, fmt.Errorf("error writing status transitions csv: %v", err)
+	}
+
+	return entries.Users(), nil
+}
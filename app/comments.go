@@ -0,0 +1,36 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"jira-export/pkg/jira"
+	"jira-export/pkg/logger"
+	"jira-export/pkg/output"
+)
+
+// exportComments writes the comments of the issues to comments.csv and
+// comments.json. The JSON file is keyed by issue key.
+// It returns the authors of the comments.
+func exportComments(ctx context.Context, jiraAPI jira.JiraAPI, issues jira.Issues, outputDir string) ([]jira.JiraIssueUser, error) {
+	keys := make([]string, len(issues))
+	for n, issue := range issues {
+		keys[n] = issue.Key
+	}
+
+	comments, err := jiraAPI.GetComments(ctx, keys)
+	if err != nil {
+		return nil, fmt.Errorf("error getting comments: %w", err)
+	}
+
+	logger.Logger.Info("Exported comments", "count", len(comments))
+
+	if err := output.WriteJSON(fmt.Sprintf("%s/comments.json", outputDir), comments.ByIssue()); err != nil {
+		return nil, fmt.Errorf("error storing comments json: %v", err)
+	}
+
+	if err := comments.WriteCSV(fmt.Sprintf("%s/comments.csv", outputDir)); err != nil {
+		return nil, fmt.Errorf("error writing comments csv: %v", err)
+	}
+
+	return comments.Users(), nil
+}
@@ -0,0 +1,42 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	FieldsCmd.AddCommand(FieldsListCmd)
+	RootCmd.AddCommand(FieldsCmd)
+}
+
+var FieldsCmd = &cobra.Command{
+	Use:   "fields",
+	Short: "Inspect the fields of the Jira instance",
+}
+
+var FieldsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the system and custom fields with ID, name, type and schema",
+	Run: func(cmd *cobra.Command, args []string) {
+		jiraAPI := mustJiraAPI()
+
+		ctx, cancel := runContext(cmd)
+		defer cancel()
+
+		fields, err := jiraAPI.GetFields(ctx)
+		if err != nil {
+			os.Exit(handleError("Listing fields failed", err))
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tTYPE\tSCHEMA\tCUSTOM")
+		for _, f := range fields {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%t\n", f.ID, f.Name, f.TypeName(), f.SchemaName(), f.Custom)
+		}
+		w.Flush()
+	},
+}
@@ -0,0 +1,65 @@
+package app
+
+import (
+	"fmt"
+	"jira-export/pkg/jira"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	FiltersCmd.AddCommand(FiltersListCmd)
+	RootCmd.AddCommand(FiltersCmd)
+}
+
+var FiltersCmd = &cobra.Command{
+	Use:   "filters",
+	Short: "Inspect the saved Jira filters",
+}
+
+var FiltersListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the favourite and owned filters of the user",
+	Run: func(cmd *cobra.Command, args []string) {
+		jiraAPI := mustJiraAPI()
+
+		ctx, cancel := runContext(cmd)
+		defer cancel()
+
+		filters, err := jiraAPI.GetMyFilters(ctx)
+		if err != nil {
+			os.Exit(handleError("Listing filters failed", err))
+		}
+
+		// Favourite filters shared by other users are not part of the own filters
+		favourites, err := jiraAPI.GetFavouriteFilters(ctx)
+		if err != nil {
+			os.Exit(handleError("Listing favourite filters failed", err))
+		}
+		filters = mergeFilters(filters, favourites)
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tOWNER\tFAVOURITE\tJQL")
+		for _, f := range filters {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%t\t%s\n", f.ID, f.Name, f.Owner.DisplayName, f.Favourite, f.JQL)
+		}
+		w.Flush()
+	},
+}
+
+// mergeFilters appends the filters of b which are not part of a
+func mergeFilters(a []jira.Filter, b []jira.Filter) []jira.Filter {
+	seen := map[string]bool{}
+	for _, f := range a {
+		seen[f.ID] = true
+	}
+	for _, f := range b {
+		if !seen[f.ID] {
+			seen[f.ID] = true
+			a = append(a, f)
+		}
+	}
+	return a
+}
@@ -0,0 +1,100 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"jira-export/pkg/jira"
+	"jira-export/pkg/logger"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var showStructure bool
+
+func init() {
+	JQLValidateCmd.Flags().BoolVar(&showStructure, "show-structure", false, "Print the parsed structure of a valid query as JSON")
+	JQLCmd.AddCommand(JQLValidateCmd)
+	RootCmd.AddCommand(JQLCmd)
+}
+
+var JQLCmd = &cobra.Command{
+	Use:   "jql",
+	Short: "Work with JQL queries",
+}
+
+var JQLValidateCmd = &cobra.Command{
+	Use:   "validate [query]",
+	Short: "Validate a JQL query with the strict JQL parser of Jira",
+	Long:  `Validate a JQL query with the strict JQL parser of Jira. The query is read from the argument or the --jql flag.`,
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		query := jql
+		if len(args) > 0 {
+			query = args[0]
+		}
+		if query == "" {
+			logger.Logger.Error("Missing JQL query")
+			os.Exit(1)
+		}
+
+		jiraAPI := mustJiraAPI()
+
+		ctx, cancel := runContext(cmd)
+		defer cancel()
+
+		v, err := jiraAPI.ValidateJQL(ctx, query)
+		if err != nil {
+			os.Exit(handleError("Validation failed", err, "jql", query))
+		}
+		if !v.Valid() {
+			logFieldSuggestions(ctx, jiraAPI, v)
+			os.Exit(handleError("Validation failed", v.Err(), "jql", query))
+		}
+
+		logger.Logger.Info("JQL query is valid", "jql", query)
+		if showStructure {
+			structure, err := json.MarshalIndent(v.Structure, "", "  ")
+			if err != nil {
+				os.Exit(handleError("Printing structure failed", err))
+			}
+			fmt.Println(string(structure))
+		}
+	},
+}
+
+// logFieldSuggestions logs the field names similar to the unknown fields of the validation errors
+func logFieldSuggestions(ctx context.Context, jiraAPI jira.JiraAPI, v jira.JQLValidation) {
+	for _, e := range v.Errors {
+		field := e.UnknownField()
+		if field == "" {
+			continue
+		}
+
+		suggestions, err := jiraAPI.SuggestFields(ctx, field)
+		if err != nil {
+			logger.Logger.Debug("Could not suggest field names", "error", err)
+		} else if len(suggestions) > 0 {
+			logger.Logger.Info("Did you mean?", "field", field, "suggestions", strings.Join(suggestions, ", "))
+		}
+	}
+}
+
+// mustBeValidJQL validates the query before an export. Jira Server has no
+// JQL parse API, so the validation is skipped there.
+func mustBeValidJQL(ctx context.Context, jiraAPI jira.JiraAPI, query string) error {
+	v, err := jiraAPI.ValidateJQL(ctx, query)
+	if errors.Is(err, jira.ErrValidationUnsupported) {
+		logger.Logger.Debug("Skipping JQL validation", "reason", err)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	logFieldSuggestions(ctx, jiraAPI, v)
+	return v.Err()
+}
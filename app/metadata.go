@@ -0,0 +1,56 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"jira-export/pkg/jira"
+	"jira-export/pkg/logger"
+	"jira-export/pkg/output"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var metadataProjects []string
+
+func init() {
+	ExportMetadataCmd.Flags().StringSliceVar(&metadataProjects, "projects", nil, "Comma separated keys of the projects whose components and versions are exported (default all projects)")
+	RootCmd.AddCommand(ExportMetadataCmd)
+}
+
+var ExportMetadataCmd = &cobra.Command{
+	Use:   "export-metadata",
+	Short: "Export projects, components, versions, issue types, priorities, resolutions and statuses as reference tables",
+	Run: func(cmd *cobra.Command, args []string) {
+		jiraAPI := mustJiraAPI()
+
+		ctx, cancel := runContext(cmd)
+		defer cancel()
+
+		if err := exportMetadata(ctx, jiraAPI, metadataProjects, outputDir); err != nil {
+			os.Exit(handleError("Metadata export failed", err))
+		}
+	},
+}
+
+// exportMetadata writes the reference data of the projects to one CSV file
+// per entity and metadata.json
+func exportMetadata(ctx context.Context, jiraAPI jira.JiraAPI, projects []string, outputDir string) error {
+	metadata, err := jiraAPI.GetMetadata(ctx, projects)
+	if err != nil {
+		return err
+	}
+
+	logger.Logger.Info("Exported metadata",
+		"projects", len(metadata.Projects),
+		"components", len(metadata.Components),
+		"versions", len(metadata.Versions),
+		"statuses", len(metadata.Statuses),
+	)
+
+	if err := output.WriteJSON(fmt.Sprintf("%s/metadata.json", outputDir), metadata); err != nil {
+		return fmt.Errorf("error storing metadata json: %v", err)
+	}
+
+	return metadata.WriteCSV(outputDir)
+}
@@ -0,0 +1,36 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"jira-export/pkg/jira"
+	"jira-export/pkg/logger"
+	"jira-export/pkg/output"
+)
+
+// exportServiceRequests writes the request types, participants and
+// organizations of the issues to jsm-requests.csv, their SLA cycles to
+// jsm-slas.csv and both to jsm.json.
+// It returns the participants of the requests.
+func exportServiceRequests(ctx context.Context, jiraAPI jira.JiraAPI, issues []jira.SearchIssue, organizationFieldIDs []string, outputDir string) ([]jira.JiraIssueUser, error) {
+	requests, err := jiraAPI.GetServiceRequests(ctx, issues, organizationFieldIDs)
+	if err != nil {
+		return nil, fmt.Errorf("error getting service requests: %w", err)
+	}
+
+	logger.Logger.Info("Exported service requests", "count", len(requests))
+
+	if err := output.WriteJSON(fmt.Sprintf("%s/jsm.json", outputDir), requests); err != nil {
+		return nil, fmt.Errorf("error storing service requests json: %v", err)
+	}
+
+	if err := requests.WriteCSV(fmt.Sprintf("%s/jsm-requests.csv", outputDir)); err != nil {
+		return nil, fmt.Errorf("error writing service requests csv: %v", err)
+	}
+
+	if err := requests.WriteSLACSV(fmt.Sprintf("%s/jsm-slas.csv", outputDir)); err != nil {
+		return nil, fmt.Errorf("error writing SLA csv: %v", err)
+	}
+
+	return requests.Users(), nil
+}
@@ -0,0 +1,36 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"jira-export/pkg/jira"
+	"jira-export/pkg/logger"
+	"jira-export/pkg/output"
+)
+
+// exportUsers writes the referenced users to users.csv and users.json and,
+// with groups, their group memberships to user-groups.csv
+func exportUsers(ctx context.Context, jiraAPI jira.JiraAPI, refs []jira.JiraIssueUser, withGroups bool, outputDir string) error {
+	users, err := jiraAPI.GetUsers(ctx, refs, withGroups)
+	if err != nil {
+		return fmt.Errorf("error getting users: %w", err)
+	}
+
+	logger.Logger.Info("Exported users", "count", len(users))
+
+	if err := output.WriteJSON(fmt.Sprintf("%s/users.json", outputDir), users); err != nil {
+		return fmt.Errorf("error storing users json: %v", err)
+	}
+
+	if err := users.WriteCSV(fmt.Sprintf("%s/users.csv", outputDir)); err != nil {
+		return fmt.Errorf("error writing users csv: %v", err)
+	}
+
+	if withGroups {
+		if err := users.WriteGroupsCSV(fmt.Sprintf("%s/user-groups.csv", outputDir)); err != nil {
+			return fmt.Errorf("error writing user groups csv: %v", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"jira-export/pkg/jira"
+	"jira-export/pkg/logger"
+	"jira-export/pkg/output"
+	"time"
+)
+
+// parseSince parses a date (2006-01-02) or an RFC 3339 timestamp
+func parseSince(s string) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return t, fmt.Errorf("invalid date %q, expected YYYY-MM-DD or RFC 3339", s)
+	}
+	return t, nil
+}
+
+// exportWorklogs writes the worklogs of the issues to worklogs.csv and
+// worklogs.json and the booked hours per user, week and epic or component to
+// timesheet.csv. If since is set, only the worklogs updated since then are
+// fetched incrementally instead of fetching the worklogs per issue.
+// It returns the authors of the worklogs.
+func exportWorklogs(ctx context.Context, jiraAPI jira.JiraAPI, issues jira.Issues, since string, timesheetBy string, outputDir string) ([]jira.JiraIssueUser, error) {
+	var worklogs jira.Worklogs
+	if since != "" {
+		sinceTime, err := parseSince(since)
+		if err != nil {
+			return nil, err
+		}
+
+		updated, err := jiraAPI.GetUpdatedWorklogs(ctx, sinceTime)
+		if err != nil {
+			return nil, fmt.Errorf("error getting updated worklogs: %w", err)
+		}
+		worklogs = updated.ForIssues(issues)
+	} else {
+		keys := make([]string, len(issues))
+		for n, issue := range issues {
+			keys[n] = issue.Key
+		}
+
+		var err error
+		worklogs, err = jiraAPI.GetWorklogs(ctx, keys)
+		if err != nil {
+			return nil, fmt.Errorf("error getting worklogs: %w", err)
+		}
+	}
+
+	logger.Logger.Info("Exported worklogs", "count", len(worklogs))
+
+	if err := output.WriteJSON(fmt.Sprintf("%s/worklogs.json", outputDir), worklogs); err != nil {
+		return nil, fmt.Errorf("error storing worklogs json: %v", err)
+	}
+
+	if err := worklogs.WriteCSV(fmt.Sprintf("%s/worklogs.csv", outputDir)); err != nil {
+		return nil, fmt.Errorf("error writing worklogs csv: %v", err)
+	}
+
+	timesheet, err := worklogs.Timesheet(issues, timesheetBy)
+	if err != nil {
+		return nil, fmt.Errorf("error creating timesheet: %v", err)
+	}
+
+	if err := timesheet.WriteCSV(fmt.Sprintf("%s/timesheet.csv", outputDir), timesheetBy); err != nil {
+		return nil, fmt.Errorf("error writing timesheet csv: %v", err)
+	}
+
+	return worklogs.Users(), nil
+}
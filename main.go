@@ -1,14 +1,29 @@
 package main
 
 import (
+	"context"
 	"jira-export/app"
 	"jira-export/pkg/logger"
+	"os"
+	"os/signal"
+	"syscall"
 )
 
 func main() {
 
 	logger.Logger.Info("Starting Jira Export")
 
+	// Cancel the context on SIGINT and SIGTERM so that in-flight requests
+	// are stopped and partial results can be written
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		<-ctx.Done()
+		// Restore the default behaviour, so a second signal terminates immediately
+		stop()
+	}()
+
 	// Use the cobra root cmd to execute the app
-	app.RootCmd.Execute()
+	app.RootCmd.ExecuteContext(ctx)
 }
@@ -0,0 +1,437 @@
+// Package adf renders the Atlassian Document Format (ADF), the rich text
+// format of Jira Cloud, as Markdown.
+//
+// Text is not escaped, so characters with a meaning in Markdown are kept as
+// they were written in Jira.
+package adf
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Node is a node of an ADF document
+type Node struct {
+	Type    string         `json:"type"`
+	Text    string         `json:"text,omitempty"`
+	Attrs   map[string]any `json:"attrs,omitempty"`
+	Marks   []Mark         `json:"marks,omitempty"`
+	Content []Node         `json:"content,omitempty"`
+}
+
+// Mark is a formatting applied to a text node, e.g. strong or link
+type Mark struct {
+	Type  string         `json:"type"`
+	Attrs map[string]any `json:"attrs,omitempty"`
+}
+
+// panelLabels are the labels of the panel types
+var panelLabels = map[string]string{
+	"info":    "Info",
+	"note":    "Note",
+	"tip":     "Tip",
+	"success": "Success",
+	"warning": "Warning",
+	"error":   "Error",
+}
+
+// IsDocument reports whether v is the root node of an ADF document as
+// decoded into generic JSON values
+func IsDocument(v any) bool {
+	m, ok := v.(map[string]any)
+	return ok && m["type"] == "doc"
+}
+
+// Parse converts a document decoded into generic JSON values, e.g. a
+// map[string]any, into a Node
+func Parse(v any) (Node, error) {
+	var n Node
+	data, err := json.Marshal(v)
+	if err != nil {
+		return n, fmt.Errorf("error encoding document: %v", err)
+	}
+	if err := json.Unmarshal(data, &n); err != nil {
+		return n, fmt.Errorf("error decoding document: %v", err)
+	}
+	return n, nil
+}
+
+// ToMarkdown parses a document decoded into generic JSON values and renders it as Markdown
+func ToMarkdown(v any) (string, error) {
+	n, err := Parse(v)
+	if err != nil {
+		return "", err
+	}
+	return n.Markdown(), nil
+}
+
+// Markdown renders the node and its content as Markdown
+func (n Node) Markdown() string {
+	if isInline(n) {
+		return renderInline([]Node{n})
+	}
+	return renderBlock(n)
+}
+
+// isInline reports whether the node is part of the text of a block
+func isInline(n Node) bool {
+	switch n.Type {
+	case "text", "hardBreak", "mention", "emoji", "date", "status", "inlineCard", "mediaInline", "placeholder":
+		return true
+	}
+	return false
+}
+
+// renderBlocks renders the block nodes separated by sep. Consecutive inline
+// nodes, e.g. the content of a task item, are rendered as one block.
+func renderBlocks(nodes []Node, sep string) string {
+	blocks := []string{}
+	for i := 0; i < len(nodes); {
+		var block string
+		if isInline(nodes[i]) {
+			j := i
+			for j < len(nodes) && isInline(nodes[j]) {
+				j++
+			}
+			block = renderInline(nodes[i:j])
+			i = j
+		} else {
+			block = renderBlock(nodes[i])
+			i++
+		}
+		if block != "" {
+			blocks = append(blocks, block)
+		}
+	}
+	return strings.Join(blocks, sep)
+}
+
+// renderBlock renders a block node without a trailing line break
+func renderBlock(n Node) string {
+	switch n.Type {
+	case "doc":
+		return renderBlocks(n.Content, "\n\n")
+	case "paragraph":
+		return renderInline(n.Content)
+	case "heading":
+		level := min(max(attrInt(n.Attrs, "level", 1), 1), 6)
+		return strings.Repeat("#", level) + " " + renderInline(n.Content)
+	case "codeBlock":
+		return renderCodeBlock(n)
+	case "blockquote":
+		return quote(renderBlocks(n.Content, "\n\n"))
+	case "rule":
+		return "---"
+	case "bulletList", "orderedList", "taskList", "decisionList":
+		return renderList(n)
+	case "table":
+		return renderTable(n)
+	case "panel":
+		label, ok := panelLabels[attrString(n.Attrs, "panelType")]
+		if !ok {
+			label = "Note"
+		}
+		return quote("**" + label + "**\n\n" + renderBlocks(n.Content, "\n\n"))
+	case "expand", "nestedExpand":
+		return "<details>\n<summary>" + attrString(n.Attrs, "title") + "</summary>\n\n" +
+			renderBlocks(n.Content, "\n\n") + "\n\n</details>"
+	case "mediaSingle", "mediaGroup":
+		return renderBlocks(n.Content, "\n")
+	case "media":
+		return renderMedia(n)
+	case "caption":
+		return "*" + renderInline(n.Content) + "*"
+	case "blockCard", "embedCard":
+		if url := attrString(n.Attrs, "url"); url != "" {
+			return "<" + url + ">"
+		}
+		return ""
+	case "extension", "inlineExtension":
+		// Extensions are rendered by the app providing them
+		return ""
+	}
+	if isInline(n) {
+		return renderInline([]Node{n})
+	}
+	return renderBlocks(n.Content, "\n\n")
+}
+
+// renderCodeBlock renders a fenced code block. The fence is longer than any
+// backtick sequence in the code.
+func renderCodeBlock(n Node) string {
+	var code strings.Builder
+	for _, c := range n.Content {
+		code.WriteString(c.Text)
+	}
+
+	fence := "```"
+	for strings.Contains(code.String(), fence) {
+		fence += "`"
+	}
+	return fence + attrString(n.Attrs, "language") + "\n" + code.String() + "\n" + fence
+}
+
+// renderList renders the items of a list. Nested lists are indented below
+// the item they belong to.
+func renderList(n Node) string {
+	start := attrInt(n.Attrs, "order", 1)
+	items := []string{}
+	number := start
+	for _, item := range n.Content {
+		marker := "- "
+		switch item.Type {
+		case "bulletList", "orderedList", "taskList", "decisionList":
+			// Nested task and decision lists are children of the list itself
+			items = append(items, indent(renderList(item), "  ", true))
+			continue
+		case "taskItem":
+			marker = "- [ ] "
+			if attrString(item.Attrs, "state") == "DONE" {
+				marker = "- [x] "
+			}
+		default:
+			if n.Type == "orderedList" {
+				marker = strconv.Itoa(number) + ". "
+				number++
+			}
+		}
+		body := renderBlocks(item.Content, "\n")
+		items = append(items, marker+indent(body, strings.Repeat(" ", len(marker)), false))
+	}
+	return strings.Join(items, "\n")
+}
+
+// renderTable renders a table as a GitHub Flavored Markdown table. The first
+// row is the header if it consists of header cells, otherwise an empty
+// header is added because Markdown tables require one.
+func renderTable(n Node) string {
+	rows := [][]string{}
+	header := false
+	columns := 0
+	for i, row := range n.Content {
+		if row.Type != "tableRow" {
+			continue
+		}
+		cells := []string{}
+		headerCells := 0
+		for _, cell := range row.Content {
+			if cell.Type == "tableHeader" {
+				headerCells++
+			}
+			cells = append(cells, renderCell(cell))
+			// Merged cells span several columns
+			for k := 1; k < attrInt(cell.Attrs, "colspan", 1); k++ {
+				cells = append(cells, "")
+			}
+		}
+		if i == 0 {
+			header = headerCells > 0 && headerCells == len(row.Content)
+		}
+		columns = max(columns, len(cells))
+		rows = append(rows, cells)
+	}
+	if len(rows) == 0 {
+		return ""
+	}
+	if !header {
+		rows = append([][]string{{}}, rows...)
+	}
+
+	lines := make([]string, 0, len(rows)+1)
+	for i, cells := range rows {
+		for len(cells) < columns {
+			cells = append(cells, "")
+		}
+		lines = append(lines, "| "+strings.Join(cells, " | ")+" |")
+		if i == 0 {
+			lines = append(lines, "|"+strings.Repeat(" --- |", columns))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderCell renders the content of a table cell on a single line
+func renderCell(cell Node) string {
+	text := renderBlocks(cell.Content, "<br>")
+	text = strings.ReplaceAll(text, "\\\n", "<br>")
+	text = strings.ReplaceAll(text, "\n", "<br>")
+	return strings.ReplaceAll(text, "|", "\\|")
+}
+
+// renderMedia renders an image or a reference to an attached file
+func renderMedia(n Node) string {
+	alt := attrString(n.Attrs, "alt")
+	if url := attrString(n.Attrs, "url"); url != "" {
+		return "![" + alt + "](" + url + ")"
+	}
+	if alt == "" {
+		alt = attrString(n.Attrs, "id")
+	}
+	return "[attachment: " + alt + "]"
+}
+
+// renderInline renders the inline nodes of a block
+func renderInline(nodes []Node) string {
+	var out strings.Builder
+	for _, n := range nodes {
+		switch n.Type {
+		case "text":
+			out.WriteString(applyMarks(n.Text, n.Marks))
+		case "hardBreak":
+			out.WriteString("\\\n")
+		case "mention":
+			text := attrString(n.Attrs, "text")
+			if text == "" {
+				text = attrString(n.Attrs, "id")
+			}
+			if !strings.HasPrefix(text, "@") {
+				text = "@" + text
+			}
+			out.WriteString(text)
+		case "emoji":
+			if text := attrString(n.Attrs, "text"); text != "" {
+				out.WriteString(text)
+			} else {
+				out.WriteString(attrString(n.Attrs, "shortName"))
+			}
+		case "date":
+			out.WriteString(formatDate(attrString(n.Attrs, "timestamp")))
+		case "status":
+			out.WriteString("[" + attrString(n.Attrs, "text") + "]")
+		case "inlineCard":
+			if url := attrString(n.Attrs, "url"); url != "" {
+				out.WriteString("<" + url + ">")
+			}
+		case "mediaInline":
+			out.WriteString(renderMedia(n))
+		case "placeholder":
+			// Placeholders are hints shown in empty templates
+		default:
+			out.WriteString(renderInline(n.Content))
+		}
+	}
+	return out.String()
+}
+
+// applyMarks formats the text with its marks
+func applyMarks(text string, marks []Mark) string {
+	if text == "" {
+		return ""
+	}
+
+	// Code spans cannot contain other formatting, so they are applied first
+	for _, m := range marks {
+		if m.Type == "code" {
+			text = codeSpan(text)
+		}
+	}
+
+	var href string
+	for _, m := range marks {
+		switch m.Type {
+		case "strong":
+			text = wrap(text, "**", "**")
+		case "em":
+			text = wrap(text, "*", "*")
+		case "strike":
+			text = wrap(text, "~~", "~~")
+		case "underline":
+			text = wrap(text, "<u>", "</u>")
+		case "subsup":
+			tag := "sub"
+			if attrString(m.Attrs, "type") == "sup" {
+				tag = "sup"
+			}
+			text = wrap(text, "<"+tag+">", "</"+tag+">")
+		case "link":
+			href = attrString(m.Attrs, "href")
+		}
+	}
+	if href != "" {
+		text = "[" + text + "](" + href + ")"
+	}
+	return text
+}
+
+// wrap encloses the text in the delimiters. Leading and trailing whitespace
+// stays outside, as emphasis must not start or end with a space.
+func wrap(text, open, close string) string {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return text
+	}
+	start := strings.Index(text, trimmed)
+	return text[:start] + open + trimmed + close + text[start+len(trimmed):]
+}
+
+// codeSpan encloses the text in more backticks than it contains in a row
+func codeSpan(text string) string {
+	fence := "`"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	if strings.HasPrefix(text, "`") || strings.HasSuffix(text, "`") {
+		return fence + " " + text + " " + fence
+	}
+	return fence + text + fence
+}
+
+// formatDate formats a date node timestamp in milliseconds as yyyy-mm-dd
+func formatDate(timestamp string) string {
+	ms, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return timestamp
+	}
+	return time.UnixMilli(ms).UTC().Format("2006-01-02")
+}
+
+// indent indents the lines of the text. The first line is only indented if first is set.
+func indent(text, prefix string, first bool) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line != "" && (first || i > 0) {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// quote prefixes the lines of the text with the block quote marker
+func quote(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = ">"
+		} else {
+			lines[i] = "> " + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// attrString returns an attribute as a string
+func attrString(attrs map[string]any, key string) string {
+	switch v := attrs[key].(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
+
+// attrInt returns an attribute as an integer or def if it is not set
+func attrInt(attrs map[string]any, key string, def int) int {
+	switch v := attrs[key].(type) {
+	case float64:
+		return int(v)
+	case string:
+		if i, err := strconv.Atoi(v); err == nil {
+			return i
+		}
+	}
+	return def
+}
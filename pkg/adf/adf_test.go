@@ -0,0 +1,125 @@
+package adf
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// markdown renders a document given as JSON
+func markdown(t *testing.T, doc string) string {
+	t.Helper()
+	var v any
+	if err := json.Unmarshal([]byte(doc), &v); err != nil {
+		t.Fatal(err)
+	}
+	md, err := ToMarkdown(v)
+	assert.NoError(t, err)
+	return md
+}
+
+// TestMarkdownText tests paragraphs, marks and inline nodes
+func TestMarkdownText(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want string
+	}{
+		{
+			name: "paragraphs keep their spacing",
+			doc: `{"type":"doc","content":[
+				{"type":"paragraph","content":[{"type":"text","text":"Hello "},{"type":"text","text":"world"}]},
+				{"type":"paragraph","content":[{"type":"text","text":"line"},{"type":"hardBreak"},{"type":"text","text":"break"}]}]}`,
+			want: "Hello world\n\nline\\\nbreak",
+		},
+		{
+			name: "marks",
+			doc: `{"type":"doc","content":[{"type":"paragraph","content":[
+				{"type":"text","text":"bold ","marks":[{"type":"strong"}]},
+				{"type":"text","text":"italic","marks":[{"type":"em"}]},{"type":"text","text":" "},
+				{"type":"text","text":"a` + "`" + `b","marks":[{"type":"code"}]},{"type":"text","text":" "},
+				{"type":"text","text":"gone","marks":[{"type":"strike"}]},{"type":"text","text":" "},
+				{"type":"text","text":"under","marks":[{"type":"underline"}]},{"type":"text","text":" "},
+				{"type":"text","text":"site","marks":[{"type":"link","attrs":{"href":"https://example.com"}},{"type":"strong"}]}]}]}`,
+			want: "**bold** *italic* ``a`b`` ~~gone~~ <u>under</u> [**site**](https://example.com)",
+		},
+		{
+			name: "inline nodes",
+			doc: `{"type":"doc","content":[{"type":"paragraph","content":[
+				{"type":"mention","attrs":{"id":"abc","text":"@Jane Doe"}},{"type":"text","text":" "},
+				{"type":"emoji","attrs":{"shortName":":smile:","text":"😄"}},{"type":"text","text":" "},
+				{"type":"emoji","attrs":{"shortName":":custom:"}},{"type":"text","text":" "},
+				{"type":"date","attrs":{"timestamp":"1704067200000"}},{"type":"text","text":" "},
+				{"type":"status","attrs":{"text":"IN PROGRESS","color":"blue"}},{"type":"text","text":" "},
+				{"type":"inlineCard","attrs":{"url":"https://example.com/browse/TEST-1"}}]}]}`,
+			want: "@Jane Doe 😄 :custom: 2024-01-01 [IN PROGRESS] <https://example.com/browse/TEST-1>",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, markdown(t, tt.doc))
+		})
+	}
+}
+
+// TestMarkdownBlocks tests headings, code blocks, quotes, panels, media and expands
+func TestMarkdownBlocks(t *testing.T) {
+	md := markdown(t, `{"type":"doc","content":[
+		{"type":"heading","attrs":{"level":2},"content":[{"type":"text","text":"Steps"}]},
+		{"type":"codeBlock","attrs":{"language":"go"},"content":[{"type":"text","text":"fmt.Println(\"hi\")"}]},
+		{"type":"blockquote","content":[{"type":"paragraph","content":[{"type":"text","text":"quoted"}]},{"type":"paragraph","content":[{"type":"text","text":"twice"}]}]},
+		{"type":"rule"},
+		{"type":"panel","attrs":{"panelType":"warning"},"content":[{"type":"paragraph","content":[{"type":"text","text":"Careful"}]}]},
+		{"type":"mediaSingle","content":[{"type":"media","attrs":{"type":"file","id":"1a2b","alt":"screenshot.png"}}]},
+		{"type":"mediaSingle","content":[{"type":"media","attrs":{"type":"external","url":"https://example.com/a.png"}}]},
+		{"type":"expand","attrs":{"title":"Details"},"content":[{"type":"paragraph","content":[{"type":"text","text":"hidden"}]}]}]}`)
+	assert.Equal(t, "## Steps\n\n"+
+		"```go\nfmt.Println(\"hi\")\n```\n\n"+
+		"> quoted\n>\n> twice\n\n"+
+		"---\n\n"+
+		"> **Warning**\n>\n> Careful\n\n"+
+		"[attachment: screenshot.png]\n\n"+
+		"![](https://example.com/a.png)\n\n"+
+		"<details>\n<summary>Details</summary>\n\nhidden\n\n</details>", md)
+}
+
+// TestMarkdownLists tests nested bullet, ordered and task lists
+func TestMarkdownLists(t *testing.T) {
+	md := markdown(t, `{"type":"doc","content":[
+		{"type":"bulletList","content":[
+			{"type":"listItem","content":[{"type":"paragraph","content":[{"type":"text","text":"one"}]},
+				{"type":"orderedList","attrs":{"order":3},"content":[
+					{"type":"listItem","content":[{"type":"paragraph","content":[{"type":"text","text":"three"}]}]},
+					{"type":"listItem","content":[{"type":"paragraph","content":[{"type":"text","text":"four"}]},
+						{"type":"bulletList","content":[{"type":"listItem","content":[{"type":"paragraph","content":[{"type":"text","text":"deep"}]}]}]}]}]}]},
+			{"type":"listItem","content":[{"type":"paragraph","content":[{"type":"text","text":"two"}]}]}]},
+		{"type":"taskList","content":[
+			{"type":"taskItem","attrs":{"state":"DONE"},"content":[{"type":"text","text":"done"}]},
+			{"type":"taskList","content":[{"type":"taskItem","attrs":{"state":"TODO"},"content":[{"type":"text","text":"todo"}]}]}]}]}`)
+	assert.Equal(t, "- one\n"+
+		"  3. three\n"+
+		"  4. four\n"+
+		"     - deep\n"+
+		"- two\n\n"+
+		"- [x] done\n"+
+		"  - [ ] todo", md)
+}
+
+// TestMarkdownTable tests tables with and without a header row
+func TestMarkdownTable(t *testing.T) {
+	cell := func(typ, text string) string {
+		return `{"type":"` + typ + `","content":[{"type":"paragraph","content":[{"type":"text","text":"` + text + `"}]}]}`
+	}
+
+	md := markdown(t, `{"type":"doc","content":[{"type":"table","content":[
+		{"type":"tableRow","content":[`+cell("tableHeader", "Name")+`,`+cell("tableHeader", "Value")+`]},
+		{"type":"tableRow","content":[`+cell("tableCell", "a|b")+`,{"type":"tableCell","content":[
+			{"type":"paragraph","content":[{"type":"text","text":"x"}]},{"type":"paragraph","content":[{"type":"text","text":"y"}]}]}]}]}]}`)
+	assert.Equal(t, "| Name | Value |\n| --- | --- |\n| a\\|b | x<br>y |", md)
+
+	md = markdown(t, `{"type":"doc","content":[{"type":"table","content":[
+		{"type":"tableRow","content":[{"type":"tableCell","attrs":{"colspan":2},"content":[]}]},
+		{"type":"tableRow","content":[`+cell("tableCell", "1")+`,`+cell("tableCell", "2")+`]}]}]}`)
+	assert.Equal(t, "|  |  |\n| --- | --- |\n|  |  |\n| 1 | 2 |", md)
+}
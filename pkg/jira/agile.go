@@ -0,0 +1,287 @@
+package jira
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"jira-export/pkg/output"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SprintFieldSchema is the custom schema of the sprint field
+const SprintFieldSchema = "com.pyxis.greenhopper.jira:gh-sprint"
+
+// Board is an agile board
+type Board struct {
+	ID       int           `json:"id"`
+	Name     string        `json:"name"`
+	Type     string        `json:"type"`
+	Location BoardLocation `json:"location"`
+}
+
+// BoardLocation is the project or user a board belongs to
+type BoardLocation struct {
+	ProjectID   int    `json:"projectId,omitempty"`
+	ProjectKey  string `json:"projectKey,omitempty"`
+	ProjectName string `json:"projectName,omitempty"`
+	DisplayName string `json:"displayName,omitempty"`
+}
+
+// Sprint is a sprint of a scrum board
+type Sprint struct {
+	ID            int    `json:"id"`
+	Name          string `json:"name"`
+	State         string `json:"state"`
+	StartDate     string `json:"startDate,omitempty"`
+	EndDate       string `json:"endDate,omitempty"`
+	CompleteDate  string `json:"completeDate,omitempty"`
+	Goal          string `json:"goal,omitempty"`
+	OriginBoardID int    `json:"originBoardId,omitempty"`
+	// BoardID is set instead of OriginBoardID in the sprint field of the issues
+	BoardID int `json:"boardId,omitempty"`
+}
+
+// IssueSprint links an issue to a sprint it was part of
+type IssueSprint struct {
+	IssueKey    string `json:"issueKey"`
+	SprintID    int    `json:"sprintId"`
+	SprintName  string `json:"sprintName"`
+	SprintState string `json:"sprintState"`
+}
+
+// Sprints contains the sprints of several boards or issues
+type Sprints []Sprint
+
+// IssueSprints contains the sprint memberships of several issues
+type IssueSprints []IssueSprint
+
+// agileURL returns the URL of an agile REST API resource, e.g. agileURL("/board")
+func (j JiraAPI) agileURL(path string) string {
+	return fmt.Sprintf("%s/rest/agile/1.0%s", strings.TrimRight(j.secrets.URL, "/"), path)
+}
+
+// GetBoards returns the boards of a project, or all boards visible to the user if the project is empty
+func (j JiraAPI) GetBoards(ctx context.Context, projectKeyOrID string) ([]Board, error) {
+	query := url.Values{}
+	if projectKeyOrID != "" {
+		query.Set("projectKeyOrId", projectKeyOrID)
+	}
+
+	boards, err := getPagedValues[Board](ctx, j, j.agileURL("/board"), query)
+	if err != nil {
+		return nil, fmt.Errorf("error getting boards: %w", err)
+	}
+	return boards, nil
+}
+
+// GetBoardSprints returns the sprints of a scrum board
+func (j JiraAPI) GetBoardSprints(ctx context.Context, boardID int) (Sprints, error) {
+	sprints, err := getPagedValues[Sprint](ctx, j, j.agileURL(fmt.Sprintf("/board/%d/sprint", boardID)), url.Values{})
+	if err != nil {
+		return nil, fmt.Errorf("error getting sprints of board %d: %w", boardID, err)
+	}
+	for n := range sprints {
+		if sprints[n].OriginBoardID == 0 {
+			sprints[n].OriginBoardID = boardID
+		}
+	}
+	return sprints, nil
+}
+
+// GetSprints returns the sprints of all scrum boards. Kanban boards have no sprints and are skipped.
+func (j JiraAPI) GetSprints(ctx context.Context, boards []Board) (Sprints, error) {
+	scrumBoards := []Board{}
+	for _, b := range boards {
+		if b.Type == "scrum" {
+			scrumBoards = append(scrumBoards, b)
+		}
+	}
+
+	sprints := make([]Sprints, len(scrumBoards))
+	err := runPool(ctx, len(scrumBoards), j.Concurrency, func(ctx context.Context, i int) error {
+		s, err := j.GetBoardSprints(ctx, scrumBoards[i].ID)
+		if err != nil {
+			return err
+		}
+		sprints[i] = s
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// A sprint can appear on several boards, so the sprints are deduplicated
+	all := Sprints{}
+	seen := map[int]bool{}
+	for _, s := range sprints {
+		for _, sprint := range s {
+			if !seen[sprint.ID] {
+				seen[sprint.ID] = true
+				all = append(all, sprint)
+			}
+		}
+	}
+	return all, nil
+}
+
+// legacySprintKeys are the attributes of the string representation of a
+// sprint returned by older Jira Server versions, e.g.
+// "com.atlassian.greenhopper.service.sprint.Sprint@1a2b[id=1,rapidViewId=2,state=CLOSED,name=Sprint 1,...]".
+// The order of the attributes differs between Jira versions, e.g. Jira 7
+// prints the goal after the name. Names and goals may contain commas, so the
+// attributes are split on ",<key>=" of the known keys only.
+var legacySprintKeys = []string{
+	"id", "rapidViewId", "state", "name", "goal", "startDate", "endDate", "completeDate",
+	"activatedDate", "sequence", "autoStartStop", "synced", "incompleteIssuesDestinationId",
+}
+
+// parseLegacySprint parses the string representation of a sprint
+func parseLegacySprint(s string) (sprint Sprint, ok bool) {
+	start := strings.Index(s, "[")
+	end := strings.LastIndex(s, "]")
+	if start < 0 || end < start {
+		return sprint, false
+	}
+	body := "," + s[start+1:end]
+
+	// Find the position of every attribute, keys missing in the Jira version are skipped
+	type attribute struct {
+		key        string
+		pos        int
+		start, end int
+	}
+	attributes := []attribute{}
+	for _, key := range legacySprintKeys {
+		if i := strings.Index(body, ","+key+"="); i >= 0 {
+			attributes = append(attributes, attribute{key: key, pos: i, start: i + len(key) + 2})
+		}
+	}
+	sort.Slice(attributes, func(a, b int) bool { return attributes[a].pos < attributes[b].pos })
+	for i := range attributes {
+		attributes[i].end = len(body)
+		if i+1 < len(attributes) {
+			attributes[i].end = attributes[i+1].pos
+		}
+	}
+
+	for _, a := range attributes {
+		value := body[a.start:a.end]
+		if value == "<null>" {
+			value = ""
+		}
+		switch a.key {
+		case "id":
+			sprint.ID, _ = strconv.Atoi(value)
+		case "rapidViewId":
+			sprint.BoardID, _ = strconv.Atoi(value)
+		case "state":
+			sprint.State = strings.ToLower(value)
+		case "name":
+			sprint.Name = value
+		case "goal":
+			sprint.Goal = value
+		case "startDate":
+			sprint.StartDate = value
+		case "endDate":
+			sprint.EndDate = value
+		case "completeDate":
+			sprint.CompleteDate = value
+		}
+	}
+	return sprint, sprint.ID != 0
+}
+
+// IssueSprintMemberships returns the sprints of the issues from the sprint
+// fields with the IDs. The sprint field contains every sprint an issue was
+// part of, so it reflects the sprint history of the issue. The unique sprints
+// are returned as well.
+func IssueSprintMemberships(issues []SearchIssue, sprintFieldIDs []string) (IssueSprints, Sprints, error) {
+	memberships := IssueSprints{}
+	sprints := Sprints{}
+	seen := map[int]bool{}
+
+	for _, issue := range issues {
+		key := issue.Key
+		for _, fieldID := range sprintFieldIDs {
+			var values []json.RawMessage
+			if _, err := issue.Field(fieldID, &values); err != nil {
+				return nil, nil, err
+			}
+
+			for _, value := range values {
+				var sprint Sprint
+				var legacy string
+				if err := json.Unmarshal(value, &legacy); err == nil {
+					s, ok := parseLegacySprint(legacy)
+					if !ok {
+						return nil, nil, fmt.Errorf("error parsing sprint of %s: %q", key, legacy)
+					}
+					sprint = s
+				} else if err := json.Unmarshal(value, &sprint); err != nil {
+					return nil, nil, fmt.Errorf("error decoding sprint of %s: %v", key, err)
+				}
+				if sprint.OriginBoardID == 0 {
+					sprint.OriginBoardID = sprint.BoardID
+				}
+
+				memberships = append(memberships, IssueSprint{
+					IssueKey:    key,
+					SprintID:    sprint.ID,
+					SprintName:  sprint.Name,
+					SprintState: sprint.State,
+				})
+				if !seen[sprint.ID] {
+					seen[sprint.ID] = true
+					sprints = append(sprints, sprint)
+				}
+			}
+		}
+	}
+
+	sort.Slice(sprints, func(a, b int) bool {
+		return sprints[a].ID < sprints[b].ID
+	})
+	return memberships, sprints, nil
+}
+
+// WriteCSV writes one row per sprint to a CSV file
+func (s Sprints) WriteCSV(filename string) error {
+	header := []string{"id", "name", "state", "startDate", "endDate", "completeDate", "goal", "boardId"}
+	rows := make([][]string, 0, len(s))
+	for _, sprint := range s {
+		rows = append(rows, []string{
+			strconv.Itoa(sprint.ID),
+			sprint.Name,
+			sprint.State,
+			sprint.StartDate,
+			sprint.EndDate,
+			sprint.CompleteDate,
+			sprint.Goal,
+			strconv.Itoa(sprint.OriginBoardID),
+		})
+	}
+	return output.WriteCSV(filename, header, rows)
+}
+
+// WriteCSV writes one row per issue and sprint to a CSV file
+func (s IssueSprints) WriteCSV(filename string) error {
+	header := []string{"key", "sprintId", "sprintName", "sprintState"}
+	rows := make([][]string, 0, len(s))
+	for _, m := range s {
+		rows = append(rows, []string{m.IssueKey, strconv.Itoa(m.SprintID), m.SprintName, m.SprintState})
+	}
+	return output.WriteCSV(filename, header, rows)
+}
+
+// WriteBoardsCSV writes one row per board to a CSV file
+func WriteBoardsCSV(filename string, boards []Board) error {
+	header := []string{"id", "name", "type", "projectKey", "projectName"}
+	rows := make([][]string, 0, len(boards))
+	for _, b := range boards {
+		rows = append(rows, []string{strconv.Itoa(b.ID), b.Name, b.Type, b.Location.ProjectKey, b.Location.ProjectName})
+	}
+	return output.WriteCSV(filename, header, rows)
+}
@@ -11,6 +11,7 @@ import (
 	"jira-export/pkg/secrets"
 	"math/rand"
 	"net/http"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
@@ -46,55 +47,141 @@ func HandleJSONDecodeError(decodeErr error, resp *http.Response) error {
 	return fmt.Errorf("error decoding JSON: %v. Response body stored in error.txt", decodeErr)
 }
 
-func NewJiraAPI(secrets secrets.Secrets, maxResults int) JiraAPI {
-	return JiraAPI{secrets: secrets, MaxResults: maxResults}
+// NewJiraAPI creates a new JiraAPI object. Without options the shared
+// default HTTP client and the default cache configuration are used.
+func NewJiraAPI(secrets secrets.Secrets, maxResults int, opts ...Option) JiraAPI {
+	j := JiraAPI{
+		secrets:        secrets,
+		MaxResults:     maxResults,
+		Flavor:         FlavorCloud,
+		client:         rutil.DefaultClient,
+		downloadClient: rutil.DefaultDownloadClient,
+		cache:          config,
+		limiter:        rutil.DefaultRateLimiter,
+		RetryPolicy:    DefaultRetryPolicy(),
+		fieldCache:     &fieldCache{},
+	}
+	for _, opt := range opts {
+		opt(&j)
+	}
+	return j
 }
 
-func makeRequest(url string, secrets secrets.Secrets) (*http.Request, error) {
+func makeRequest(ctx context.Context, url string, secrets secrets.Secrets) (*http.Request, error) {
 	// Make HTTP request to Jira API to get filter data
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("error creating GET request: %v", err)
 	}
-	req.SetBasicAuth(secrets.Username, secrets.Token)
+	secrets.SetAuth(req)
 	req.Header.Add("Accept", "application/json")
 	req.Header.Add("Content-Type", "application/json")
 	return req, nil
 }
 
+// APIFlavor selects between the Jira Cloud and the Jira Server / Data Center REST API
+type APIFlavor string
+
+const (
+	// FlavorCloud uses the REST API v3 of Jira Cloud
+	FlavorCloud APIFlavor = "cloud"
+	// FlavorServer uses the REST API v2 of Jira Server / Data Center
+	FlavorServer APIFlavor = "server"
+)
+
+// ParseAPIFlavor parses the API flavor from a string. An empty string defaults to cloud.
+func ParseAPIFlavor(s string) (APIFlavor, error) {
+	switch APIFlavor(strings.ToLower(s)) {
+	case "", FlavorCloud:
+		return FlavorCloud, nil
+	case FlavorServer, "datacenter", "dc":
+		return FlavorServer, nil
+	}
+	return "", fmt.Errorf("unknown API flavor %q", s)
+}
+
 type JiraAPI struct {
-	secrets    secrets.Secrets
+	secrets secrets.Secrets
+	// MaxResults is the page size of the search requests
 	MaxResults int
+	// Limit caps the total number of exported issues. Zero means no limit.
+	Limit int
+	// Concurrency is the number of parallel requests
+	Concurrency int
+	// Fields are the IDs of the fields returned by a search. All navigable fields are returned if empty.
+	Fields []string
+	// Expand are the entities expanded in the search results, e.g. "changelog" or "renderedFields"
+	Expand []string
+	// Flavor selects the REST API version. Defaults to cloud.
+	Flavor APIFlavor
+	// RequestTimeout is the deadline of a single request attempt. Zero means no deadline.
+	RequestTimeout time.Duration
+	// LegacySearch uses the deprecated /rest/api/3/search endpoint with
+	// startAt based pagination instead of the enhanced JQL search endpoint
+	LegacySearch bool
+	// RetryPolicy defines which failed requests are retried
+	RetryPolicy RetryPolicy
+	client      rutil.Doer
+	// downloadClient has no overall timeout, so large attachments are not cut off
+	downloadClient rutil.Doer
+	cache          *rutil.CacheConfig
+	limiter        *rutil.RateLimiter
+	fieldCache     *fieldCache
 }
 
-// GetFilterResult returns the Jira Issues for a given filter
-func (j JiraAPI) GetFilterResults(jql string) (results JiraSearchResults, err error) {
+// apiVersion returns the REST API version of the flavor
+func (j JiraAPI) apiVersion() string {
+	if j.Flavor == FlavorServer {
+		return "2"
+	}
+	return "3"
+}
+
+// apiURL returns the URL of a REST API resource, e.g. apiURL("/search")
+func (j JiraAPI) apiURL(path string) string {
+	return fmt.Sprintf("%s/rest/api/%s%s", strings.TrimRight(j.secrets.URL, "/"), j.apiVersion(), path)
+}
+
+// newCachedRequest wraps the request into a CachedRequest using the client of the JiraAPI
+func (j JiraAPI) newCachedRequest(req *http.Request) *rutil.CachedRequest {
+	return rutil.NewCachedRequest(req, j.client)
+}
+
+// GetFilterResult returns the Jira Issues for a given filter.
+// If the context is cancelled, the issues fetched so far are returned together with the error.
+func (j JiraAPI) GetFilterResults(ctx context.Context, jql string) (results JiraSearchResults, err error) {
+	// Jira Server / Data Center only provides the startAt based search
+	if j.LegacySearch || j.Flavor == FlavorServer {
+		return j.getLegacySearchResults(ctx, jql)
+	}
+	return j.getJQLSearchResults(ctx, jql)
+}
+
+// getLegacySearchResults returns the Jira Issues for a given filter using the
+// deprecated search endpoint. The pages are fetched in parallel based on the total count.
+func (j JiraAPI) getLegacySearchResults(ctx context.Context, jql string) (results JiraSearchResults, err error) {
 	// Build the search URL
-	url := fmt.Sprintf("%s/rest/api/3/search", j.secrets.URL)
+	url := j.apiURL("/search")
 
 	// Prepare the cache directory
-	if err := config.PrepareCacheDir(); err != nil {
+	if err := j.cache.PrepareCacheDir(); err != nil {
 		return results, fmt.Errorf("error preparing cache directory: %v", err)
 	}
 
 	// Build the request object
-	req, err := buildSearchRequest(url, j.secrets, jql, j.MaxResults)
+	req, err := buildSearchRequest(ctx, url, j.secrets, jql, j.pageSize())
 	if err != nil {
 		return results, fmt.Errorf("error building search request: %v", err)
 	}
+	j.setSearchFields(req, "")
 
 	// Send the request with incremental backoff using the CachedRequest function
-	resp, err := sendRequestWithBackoff(req, config)
+	resp, err := j.sendRequestWithBackoff(ctx, req)
 	if err != nil {
-		return results, fmt.Errorf("error sending search request: %v", err)
+		return results, fmt.Errorf("error sending search request: %w", err)
 	}
 	defer resp.Body.Close()
 
-	// Check for authentication error
-	if resp.StatusCode == http.StatusUnauthorized {
-		return results, fmt.Errorf("authentication failed: received 401 Unauthorized")
-	}
-
 	// Decode the response body
 	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
 		return results, HandleJSONDecodeError(err, resp)
@@ -102,170 +189,343 @@ func (j JiraAPI) GetFilterResults(jql string) (results JiraSearchResults, err er
 
 	// Check for errors in the response
 	// The response may include an "errorMessages" field in case of a wrong search query
-	if results.ErrorMessages != nil {
-		return results, fmt.Errorf("error in response: %v", results.ErrorMessages)
+	if err := results.checkMessages(); err != nil {
+		return results, err
+	}
+
+	// Only fetch up to the limit
+	total := results.Total
+	if j.Limit > 0 && total > j.Limit {
+		total = j.Limit
 	}
 
-	// Fetch additional pages of results if necessary
-	if results.Total > results.MaxResults {
-		additionalData, err := j.fetchAdditionalResults(req, config, results.MaxResults, results.Total)
+	// Fetch additional pages of results if necessary. Jira may cap the
+	// page size, so the page size returned by Jira is used.
+	pageSize := results.MaxResults
+	if pageSize <= 0 {
+		pageSize = len(results.Issues)
+	}
+	if pageSize > 0 && total > len(results.Issues) {
+		additionalData, err := j.fetchAdditionalResults(ctx, req, len(results.Issues), pageSize, total)
+		results.Issues = append(results.Issues, additionalData...)
 		if err != nil {
-			return results, fmt.Errorf("error fetching additional results: %v", err)
+			return results, fmt.Errorf("error fetching additional results: %w", err)
 		}
-		results.Issues = append(results.Issues, additionalData...)
 	}
 
+	results.Issues = j.applyLimit(results.Issues)
 	return results, nil
 }
 
-// sendRequestWithBackoff sends an HTTP request with incremental backoff using the CachedRequest function
-func sendRequestWithBackoff(req *http.Request, config *rutil.CacheConfig) (*http.Response, error) {
-	backoff := time.Second
-	cr := rutil.NewCachedRequest(req)
-	for {
-		resp, err := cr.Cache(config)
-		if err != nil {
-			time.Sleep(backoff)
-			backoff *= 2
-			if backoff > time.Minute {
-				backoff = time.Minute
-			}
-			continue
-		}
+// getJSON sends a GET request to the url with incremental backoff and decodes the JSON response into v
+func (j JiraAPI) getJSON(ctx context.Context, url string, v any) error {
+	// Prepare the cache directory
+	if err := j.cache.PrepareCacheDir(); err != nil {
+		return fmt.Errorf("error preparing cache directory: %v", err)
+	}
+
+	req, err := makeRequest(ctx, url, j.secrets)
+	if err != nil {
+		return fmt.Errorf("error preparing GET request: %v", err)
+	}
 
-		// Check for authentication error
-		if resp.StatusCode == http.StatusUnauthorized {
-			return nil, fmt.Errorf("authentication failed: received 401 Unauthorized")
+	resp, err := j.sendRequestWithBackoff(ctx, req)
+	if err != nil {
+		return fmt.Errorf("error sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return HandleJSONDecodeError(err, resp)
+	}
+	return nil
+}
+
+// valuesPage is a page of the REST APIs which return the items as values
+type valuesPage[T any] struct {
+	StartAt    int  `json:"startAt"`
+	MaxResults int  `json:"maxResults"`
+	Total      int  `json:"total"`
+	IsLast     bool `json:"isLast"`
+	Values     []T  `json:"values"`
+}
+
+// getPagedValues fetches all pages of a resource returning valuesPages
+func getPagedValues[T any](ctx context.Context, j JiraAPI, u string, query url.Values) ([]T, error) {
+	values := []T{}
+	for startAt := 0; ; {
+		query.Set("startAt", strconv.Itoa(startAt))
+		query.Set("maxResults", "50")
+
+		var page valuesPage[T]
+		if err := j.getJSON(ctx, u+"?"+query.Encode(), &page); err != nil {
+			return nil, err
 		}
+		values = append(values, page.Values...)
+		startAt += len(page.Values)
 
-		if resp.StatusCode != http.StatusOK {
-			resp.Body.Close()
-			time.Sleep(backoff)
-			backoff *= 2
-			if backoff > time.Minute {
-				backoff = time.Minute
-			}
-			continue
+		if page.IsLast || len(page.Values) == 0 {
+			return values, nil
 		}
-		backoff = time.Second
-		return resp, nil
 	}
 }
 
-func responseIsTooManyRequests(r io.Reader) bool {
-	// Create a new *bytes.Reader using the io.Reader
-	reader := io.LimitReader(r, 100)
+// postJSON sends a POST request with the JSON encoded body to the url with
+// incremental backoff and decodes the JSON response into v
+func (j JiraAPI) postJSON(ctx context.Context, url string, body any, v any) error {
+	// Prepare the cache directory
+	if err := j.cache.PrepareCacheDir(); err != nil {
+		return fmt.Errorf("error preparing cache directory: %v", err)
+	}
 
-	// Read the first 100 bytes of the response body
-	first100Bytes := make([]byte, 100)
-	_, err := reader.Read(first100Bytes)
-	if err != nil && err != io.EOF {
-		logger.Logger.Error("error reading response body in responseIsTooManyRequests", "error", err)
-		return false
+	req, err := makePostRequest(ctx, url, j.secrets, body)
+	if err != nil {
+		return err
 	}
 
-	// Check if the response body starts with "<!DOCTYPE html>"
-	// if true, then the response is an HTML page and not JSON
-	// and the request was rate limited
-	return strings.HasPrefix(string(first100Bytes), "<!DOCTYPE html>")
+	resp, err := j.sendRequestWithBackoff(ctx, req)
+	if err != nil {
+		return fmt.Errorf("error sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return HandleJSONDecodeError(err, resp)
+	}
+	return nil
 }
 
-// fetchAdditionalResults fetches additional pages of Jira search results
-func (j JiraAPI) fetchAdditionalResults(req *http.Request, config *rutil.CacheConfig, startAt int, total int) ([]interface{}, error) {
-	additionalData := []interface{}{}
+// makePostRequest prepares a POST request with the JSON encoded body. The body
+// can be rewound for every attempt.
+func makePostRequest(ctx context.Context, url string, secrets secrets.Secrets, body any) (*http.Request, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling request body: %v", err)
+	}
 
-	// Build the search queries
-	rs := buildSearchRequests(req, startAt, total)
+	req, err := makeRequest(ctx, url, secrets)
+	if err != nil {
+		return nil, fmt.Errorf("error preparing POST request: %v", err)
+	}
+	req.Method = http.MethodPost
+	req.ContentLength = int64(len(data))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	return req, nil
+}
+
+// pageSize returns the number of issues requested per page, capped by the limit
+func (j JiraAPI) pageSize() int {
+	if j.Limit > 0 && j.Limit < j.MaxResults {
+		return j.Limit
+	}
+	return j.MaxResults
+}
+
+// applyLimit truncates the issues to the limit
+func (j JiraAPI) applyLimit(issues []SearchIssue) []SearchIssue {
+	if j.Limit > 0 && len(issues) > j.Limit {
+		return issues[:j.Limit]
+	}
+	return issues
+}
+
+// sleepContext pauses for the given duration or until the context is cancelled
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
 
-	results := make(chan []interface{})
-	defer close(results)
+// sendCachedRequest sends a single request attempt using the CachedRequest function.
+// The attempt is bound to the RequestTimeout of the JiraAPI and waits for the
+// shared rate limiter unless the response is served from the cache.
+func (j JiraAPI) sendCachedRequest(ctx context.Context, req *http.Request) (*http.Response, error) {
+	if j.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, j.RequestTimeout)
+		// The response body is read into the cache file before Cache returns,
+		// so the context can be cancelled right away.
+		defer cancel()
+	}
+
+	req, err := rewindBody(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
 
-	// Send the requests in batches of 10
-	for i := 0; i < len(rs); i += 10 {
-		end := i + 10
-		if end > len(rs) {
-			end = len(rs)
+	cr := j.newCachedRequest(req)
+	if !cr.IsCached(j.cache) {
+		if err := j.limiter.Wait(ctx); err != nil {
+			return nil, err
 		}
+	}
+	return cr.Cache(j.cache)
+}
 
-		// Send the requests in parallel
-		for _, r := range rs[i:end] {
-			go func(r *http.Request) {
-
-				cr := rutil.NewCachedRequest(r)
-
-				backoff := 1.0
-
-				// Send the request with incremental backoff
-				for {
-
-					// Send the request with incremental backoff using the CachedRequest function
-					resp, err := cr.Cache(config)
-					if err != nil {
-						logger.Logger.Error("Error sending request", "error", err)
-						results <- nil
-						return
-					}
-
-					// Check for authentication error
-					if resp.StatusCode == http.StatusUnauthorized {
-						results <- nil
-						return
-					}
-
-					// CHeck if the responseBody can be read
-					respBodyBytes, err := io.ReadAll(resp.Body)
-					if err != nil {
-						logger.Logger.Error("Error reading response body:", err)
-						results <- nil
-						return
-					}
-
-					// Check if the response is a rate limit error
-					r := bytes.NewReader(respBodyBytes)
-					r2 := bytes.NewReader(respBodyBytes)
-
-					if responseIsTooManyRequests(r) {
-						sleepTime := time.Duration(float64(rand.Intn(500)+500) * backoff)
-						time.Sleep(sleepTime * time.Millisecond)
-						logger.Logger.Info("Rate limit error. Delaying further requests.", "delay_ms", sleepTime)
-						// Clear cache file
-						cr.ClearCacheFile(config)
-
-						backoff *= 2
-						continue
-					}
-
-					var data JiraSearchResults
-
-					// Decode the response body
-					if err := json.NewDecoder(r2).Decode(&data); err != nil {
-						logger.Logger.Error("Error decoding JSON from response body", "error", err)
-						results <- nil
-						return
-					}
-
-					defer resp.Body.Close()
-
-					results <- data.Issues
-					break
-				}
-			}(r)
+// sendRequestWithBackoff sends an HTTP request with incremental backoff using the CachedRequest function.
+// Only network errors and the retryable statuses of the RetryPolicy are retried,
+// all other unsuccessful responses are returned as *APIError. Throttled responses
+// (429 / 503) pause all requests sharing the rate limiter for the delay requested by Jira.
+func (j JiraAPI) sendRequestWithBackoff(ctx context.Context, req *http.Request) (*http.Response, error) {
+	return j.retry(ctx, req, j.sendCachedRequest)
+}
+
+// sendUncachedRequest sends a single request attempt bypassing the cache.
+// The response body is read by the caller after the attempt, so the attempt
+// is not bound to the RequestTimeout. The timeout of the HTTP client applies.
+func (j JiraAPI) sendUncachedRequest(ctx context.Context, req *http.Request) (*http.Response, error) {
+	return j.sendWithClient(ctx, req, j.client)
+}
+
+// sendDownloadRequest sends a single download attempt using the download
+// client, which has no overall timeout. The download is cancelled through the context.
+func (j JiraAPI) sendDownloadRequest(ctx context.Context, req *http.Request) (*http.Response, error) {
+	return j.sendWithClient(ctx, req, j.downloadClient)
+}
+
+// sendWithClient sends a single request attempt bypassing the cache using the client
+func (j JiraAPI) sendWithClient(ctx context.Context, req *http.Request, client rutil.Doer) (*http.Response, error) {
+	if err := j.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	req, err := rewindBody(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return rutil.NewCachedRequest(req, client).SendRequest()
+}
+
+// rewindBody resets the body of the request, it was consumed by a previous attempt
+func rewindBody(req *http.Request) (*http.Request, error) {
+	if req.GetBody == nil {
+		return req, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, fmt.Errorf("error rewinding request body: %v", err)
+	}
+	req.Body = body
+	return req, nil
+}
+
+// retry sends the request using the send function until it succeeds or the
+// RetryPolicy gives up. See sendRequestWithBackoff.
+func (j JiraAPI) retry(ctx context.Context, req *http.Request, send func(context.Context, *http.Request) (*http.Response, error)) (*http.Response, error) {
+	policy := j.RetryPolicy
+	backoff := policy.BaseDelay
+	if backoff <= 0 {
+		backoff = time.Second
+	}
+
+	for attempt := 1; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		resp, err := send(ctx, req)
+		if err == nil && resp.StatusCode == http.StatusOK {
+			if rutil.IsNearRateLimit(resp) {
+				logger.Logger.Debug("Rate limit nearly reached. Slowing down.")
+				j.limiter.SlowDown()
+			} else {
+				j.limiter.Success()
+			}
+			return resp, nil
 		}
 
-		for j := 0; j < end-i; j++ {
-			r := <-results
-			if r == nil {
-				return nil, fmt.Errorf("parallel send failed with error %v", r)
+		var lastErr error
+		delay := backoff
+		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return nil, ctxErr
 			}
-			additionalData = append(additionalData, r...)
+			lastErr = err
+		} else {
+			apiErr := newAPIError(req, resp)
+			if !policy.isRetryable(resp.StatusCode) {
+				return nil, apiErr
+			}
+			lastErr = apiErr
+
+			if rutil.IsThrottled(resp) {
+				retryAfter, ok := rutil.RetryAfter(resp, time.Now())
+				if !ok {
+					// Add jitter so parallel requests do not retry at the same time
+					retryAfter = backoff + time.Duration(rand.Int63n(int64(backoff/2)+1))
+				}
+				logger.Logger.Info("Rate limited by Jira. Delaying further requests.", "status", resp.StatusCode, "delay", retryAfter)
+				// The shared limiter pauses all requests, so no extra delay is needed
+				j.limiter.Throttle(retryAfter)
+				delay = 0
+			}
+		}
+
+		if policy.exhausted(attempt) {
+			return nil, fmt.Errorf("giving up after %d attempts: %w", attempt, lastErr)
 		}
+
+		logger.Logger.Debug("Retrying request", "attempt", attempt, "error", lastErr)
+		if err := sleepContext(ctx, delay); err != nil {
+			return nil, err
+		}
+		backoff = policy.nextDelay(backoff)
 	}
-	return additionalData, nil
+}
+
+// ThrottledTime returns the total time requests were paused due to rate limiting
+func (j JiraAPI) ThrottledTime() time.Duration {
+	return j.limiter.ThrottledTime()
+}
+
+// fetchAdditionalResults fetches the additional pages of Jira search results
+// in parallel using a pool of Concurrency workers. The pages are reassembled in
+// page order, so the ORDER BY of the JQL query is preserved. The first error
+// cancels the remaining requests. The results fetched so far are returned
+// together with the error.
+func (j JiraAPI) fetchAdditionalResults(ctx context.Context, req *http.Request, startAt int, pageSize int, total int) ([]SearchIssue, error) {
+	additionalData := []SearchIssue{}
+
+	// Build the search queries
+	rs := buildSearchRequests(req, startAt, pageSize, total)
+	pages := make([][]SearchIssue, len(rs))
+
+	err := runPool(ctx, len(rs), j.Concurrency, func(ctx context.Context, i int) error {
+		resp, err := j.sendRequestWithBackoff(ctx, rs[i])
+		if err != nil {
+			return fmt.Errorf("error fetching page %d: %w", i+2, err)
+		}
+		defer resp.Body.Close()
+
+		var data JiraSearchResults
+
+		// Decode the response body
+		if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+			return fmt.Errorf("error decoding JSON of page %d: %v", i+2, err)
+		}
+
+		pages[i] = data.Issues
+		return nil
+	})
+
+	// Reassemble the pages in order. Pages missing due to an error are skipped.
+	for _, page := range pages {
+		additionalData = append(additionalData, page...)
+	}
+
+	return additionalData, err
 }
 
 // buildSearchRequest builds a GET request object for a Jira search query
-func buildSearchRequest(url string, secrets secrets.Secrets, jql string, maxResults int) (*http.Request, error) {
-	req, err := makeRequest(url, secrets)
+func buildSearchRequest(ctx context.Context, url string, secrets secrets.Secrets, jql string, maxResults int) (*http.Request, error) {
+	req, err := makeRequest(ctx, url, secrets)
 	if err != nil {
 		return nil, fmt.Errorf("error preparing GET request: %v", err)
 	}
@@ -281,17 +541,30 @@ func buildSearchRequest(url string, secrets secrets.Secrets, jql string, maxResu
 	return req, nil
 }
 
-// buildSearchRequests builds a slice of search query strings for fetching additional pages of Jira search results
-func buildSearchRequests(req *http.Request, startAt int, total int) (r []*http.Request) {
-	// maxResults := total - startAt
-	maxResults := 100
-	for i := startAt; i < total; i += maxResults {
-		q := req.URL.Query()
+// setSearchFields sets the fields and expand parameters of a search request
+func (j JiraAPI) setSearchFields(req *http.Request, defaultFields string) {
+	q := req.URL.Query()
+	if len(j.Fields) > 0 {
+		q.Set("fields", strings.Join(j.Fields, ","))
+	} else if defaultFields != "" {
+		q.Set("fields", defaultFields)
+	}
+	if len(j.Expand) > 0 {
+		q.Set("expand", strings.Join(j.Expand, ","))
+	}
+	req.URL.RawQuery = q.Encode()
+}
+
+// buildSearchRequests builds a slice of search requests for fetching additional pages of Jira search results
+func buildSearchRequests(req *http.Request, startAt int, pageSize int, total int) (r []*http.Request) {
+	for i := startAt; i < total; i += pageSize {
+		pageReq := req.Clone(req.Context())
+		q := pageReq.URL.Query()
 		q.Set("startAt", strconv.Itoa(i))
-		q.Set("maxResults", strconv.Itoa(maxResults))
-		req.URL.RawQuery = q.Encode()
+		q.Set("maxResults", strconv.Itoa(pageSize))
+		pageReq.URL.RawQuery = q.Encode()
 
-		r = append(r, req.Clone(context.Background()))
+		r = append(r, pageReq)
 	}
 	return r
 }
@@ -6,11 +6,16 @@ package jira
 // this is where the program will fail if the Jira API changes.
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
+	rutil "jira-export/pkg/request_util"
+	"jira-export/pkg/secrets"
 	"net/http"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -26,6 +31,41 @@ func (c *MockHTTPClient) Do(req *http.Request) (*http.Response, error) {
 	return c.Response, nil
 }
 
+// FuncHTTPClient is a mock HTTP client that answers requests using a function
+type FuncHTTPClient func(req *http.Request) (*http.Response, error)
+
+// Do calls the function
+func (f FuncHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// jsonResponse returns a response with status 200 and the given body
+func jsonResponse(body string) *http.Response {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+	}
+}
+
+// newTestAPI returns a JiraAPI for the test site that sends its requests to
+// client and caches the responses in a temporary directory
+func newTestAPI(t *testing.T, client rutil.Doer, opts ...Option) JiraAPI {
+	opts = append([]Option{
+		WithHTTPClient(client),
+		WithCacheConfig(&rutil.CacheConfig{OutputDir: t.TempDir()}),
+	}, opts...)
+	return NewJiraAPI(secrets.Secrets{URL: "https://testurl.atlassian.net"}, 50, opts...)
+}
+
+// searchIssues converts raw issues as decoded into interface{} into SearchIssues
+func searchIssues(t *testing.T, raw ...any) []SearchIssue {
+	issues := make([]SearchIssue, len(raw))
+	for n, r := range raw {
+		assert.NoError(t, decodeRaw(r, &issues[n]))
+	}
+	return issues
+}
+
 // TestHandleJSONDecodeError tests the HandleJSONDecodeError function
 func TestHandleJSONDecodeError(t *testing.T) {
 	// Create a temporary file to store the response body
@@ -46,3 +86,474 @@ func TestHandleJSONDecodeError(t *testing.T) {
 	err = os.Remove("error.txt")
 	assert.NoError(t, err)
 }
+
+// TestGetFilterResultsWithMockClient tests that an injected client is used for requests
+func TestGetFilterResultsWithMockClient(t *testing.T) {
+	client := &MockHTTPClient{
+		Response: &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"startAt":0,"maxResults":50,"total":1,"issues":[{"key":"TEST-1","fields":{"summary":"Test"}}]}`)),
+		},
+	}
+
+	api := newTestAPI(t, client, WithLegacySearch(true))
+
+	results, err := api.GetFilterResults(context.Background(), "project = TEST")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, results.Total)
+	assert.Len(t, results.Issues, 1)
+}
+
+// TestGetFilterResultsFollowsNextPageToken tests the token based pagination of the enhanced JQL search
+func TestGetFilterResultsFollowsNextPageToken(t *testing.T) {
+	client := FuncHTTPClient(func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, "/rest/api/3/search/jql", req.URL.Path)
+		assert.Equal(t, "*navigable", req.URL.Query().Get("fields"))
+
+		switch req.URL.Query().Get("nextPageToken") {
+		case "":
+			return jsonResponse(`{"issues":[{"key":"TEST-1"},{"key":"TEST-2"}],"nextPageToken":"page2"}`), nil
+		case "page2":
+			return jsonResponse(`{"issues":[{"key":"TEST-3"}],"isLast":true}`), nil
+		}
+		return nil, fmt.Errorf("unexpected request %s", req.URL)
+	})
+
+	api := newTestAPI(t, client)
+	api.MaxResults = 2
+
+	results, err := api.GetFilterResults(context.Background(), "project = TEST")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, results.Total)
+	assert.Len(t, results.Issues, 3)
+}
+
+// TestGetFilterResultsServerFlavor tests that Jira Server uses the REST API v2 and bearer auth
+func TestGetFilterResultsServerFlavor(t *testing.T) {
+	client := FuncHTTPClient(func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, "/rest/api/2/search", req.URL.Path)
+		assert.Equal(t, "Bearer testtoken", req.Header.Get("Authorization"))
+		return jsonResponse(`{"startAt":0,"maxResults":50,"total":1,"issues":[{"key":"TEST-1","fields":{"description":"h1. Wiki"}}]}`), nil
+	})
+
+	api := newTestAPI(t, client, WithFlavor(FlavorServer))
+	api.secrets = secrets.Secrets{URL: "https://jira.example.com", Token: "testtoken", AuthMode: secrets.AuthModeBearer}
+
+	results, err := api.GetFilterResults(context.Background(), "project = TEST")
+	assert.NoError(t, err)
+
+	issues, err := results.IssuesToJiraIssues()
+	assert.NoError(t, err)
+	assert.Equal(t, "h1. Wiki", issues[0].Description)
+}
+
+// TestGetFilterResultsReturnsPartialResultsOnCancel tests that the issues fetched
+// before the context was cancelled are returned
+func TestGetFilterResultsReturnsPartialResultsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := FuncHTTPClient(func(req *http.Request) (*http.Response, error) {
+		// Cancel the run after the first page was sent
+		cancel()
+		return jsonResponse(`{"issues":[{"key":"TEST-1"}],"nextPageToken":"page2"}`), nil
+	})
+
+	api := newTestAPI(t, client)
+	api.MaxResults = 1
+
+	results, err := api.GetFilterResults(ctx, "project = TEST")
+	assert.Error(t, err)
+	assert.Len(t, results.Issues, 1)
+}
+
+// TestSendRequestWithBackoffHonorsRetryAfter tests that a throttled request is retried
+func TestSendRequestWithBackoffHonorsRetryAfter(t *testing.T) {
+	calls := 0
+	client := FuncHTTPClient(func(req *http.Request) (*http.Response, error) {
+		calls++
+		if calls == 1 {
+			return &http.Response{
+				StatusCode: http.StatusTooManyRequests,
+				Header:     http.Header{"Retry-After": []string{"0"}},
+				Body:       ioutil.NopCloser(strings.NewReader("<!DOCTYPE html>")),
+			}, nil
+		}
+		return jsonResponse(`{"issues":[{"key":"TEST-1"}],"isLast":true}`), nil
+	})
+
+	api := newTestAPI(t, client, WithRateLimiter(rutil.NewRateLimiter(0, 1)))
+
+	results, err := api.GetFilterResults(context.Background(), "project = TEST")
+	assert.NoError(t, err)
+	assert.Len(t, results.Issues, 1)
+	assert.Equal(t, 2, calls)
+}
+
+// TestGetFilterResultsInvalidJQL tests that a bad request is not retried and returns ErrInvalidJQL
+func TestGetFilterResultsInvalidJQL(t *testing.T) {
+	calls := 0
+	client := FuncHTTPClient(func(req *http.Request) (*http.Response, error) {
+		calls++
+		return &http.Response{
+			StatusCode: http.StatusBadRequest,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"errorMessages":["Field 'foo' does not exist or you do not have permission to view it."],"warningMessages":[]}`)),
+		}, nil
+	})
+
+	api := newTestAPI(t, client)
+
+	_, err := api.GetFilterResults(context.Background(), "foo = bar")
+	assert.ErrorIs(t, err, ErrInvalidJQL)
+	assert.Equal(t, 1, calls)
+
+	var apiErr *APIError
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, []string{"Field 'foo' does not exist or you do not have permission to view it."}, apiErr.ErrorMessages)
+
+	// A bad request to other search endpoints is not caused by the JQL query
+	req, _ := http.NewRequest(http.MethodGet, "https://testurl.atlassian.net/rest/api/3/filter/search", nil)
+	resp := &http.Response{StatusCode: http.StatusBadRequest, Body: ioutil.NopCloser(strings.NewReader(`{}`))}
+	assert.NotErrorIs(t, newAPIError(req, resp), ErrInvalidJQL)
+}
+
+// TestSendRequestWithBackoffGivesUp tests that the retry policy limits the number of attempts
+func TestSendRequestWithBackoffGivesUp(t *testing.T) {
+	calls := 0
+	client := FuncHTTPClient(func(req *http.Request) (*http.Response, error) {
+		calls++
+		return &http.Response{
+			StatusCode: http.StatusTooManyRequests,
+			Header:     http.Header{"Retry-After": []string{"0"}},
+			Body:       ioutil.NopCloser(strings.NewReader("")),
+		}, nil
+	})
+
+	api := newTestAPI(t, client,
+		WithRateLimiter(rutil.NewRateLimiter(0, 1)),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 3, RetryableStatuses: []int{http.StatusTooManyRequests}}),
+	)
+
+	_, err := api.GetFilterResults(context.Background(), "project = TEST")
+	assert.ErrorIs(t, err, ErrRateLimited)
+	assert.Equal(t, 3, calls)
+}
+
+// TestGetFilterResultsPreservesPageOrder tests that the pages of the legacy
+// search are reassembled in order and the limit is applied
+func TestGetFilterResultsPreservesPageOrder(t *testing.T) {
+	client := FuncHTTPClient(func(req *http.Request) (*http.Response, error) {
+		startAt := req.URL.Query().Get("startAt")
+		if startAt == "" {
+			startAt = "0"
+		}
+		// Later pages answer faster than earlier pages
+		if startAt == "2" {
+			time.Sleep(20 * time.Millisecond)
+		}
+		return jsonResponse(fmt.Sprintf(`{"startAt":%s,"maxResults":2,"total":7,"issues":[{"key":"TEST-%s-a"},{"key":"TEST-%s-b"}]}`, startAt, startAt, startAt)), nil
+	})
+
+	api := newTestAPI(t, client,
+		WithRateLimiter(rutil.NewRateLimiter(0, 1)),
+		WithLegacySearch(true),
+		WithConcurrency(3),
+		WithLimit(5),
+	)
+	api.MaxResults = 2
+
+	results, err := api.GetFilterResults(context.Background(), "project = TEST ORDER BY key")
+	assert.NoError(t, err)
+
+	keys := []string{}
+	for _, issue := range results.Issues {
+		keys = append(keys, issue.Key)
+	}
+	assert.Equal(t, []string{"TEST-0-a", "TEST-0-b", "TEST-2-a", "TEST-2-b", "TEST-4-a"}, keys)
+}
+
+// TestResolveFieldIDs tests the translation of field names to IDs
+func TestResolveFieldIDs(t *testing.T) {
+	client := FuncHTTPClient(func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, "/rest/api/3/field", req.URL.Path)
+		return jsonResponse(`[{"id":"summary","name":"Summary"},{"id":"customfield_10016","name":"Story Points","custom":true}]`), nil
+	})
+
+	api := newTestAPI(t, client)
+
+	ids, err := api.ResolveFieldIDs(context.Background(), []string{"summary", "story points", "-comment"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"summary", "customfield_10016", "-comment"}, ids)
+
+	ids, err = api.ResolveFieldIDs(context.Background(), []string{"-comment"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"*navigable", "-comment"}, ids)
+	assert.False(t, IsFieldSubset(ids))
+
+	_, err = api.ResolveFieldIDs(context.Background(), []string{"Unknown"})
+	assert.Error(t, err)
+}
+
+// TestResolveFilterByName tests that a filter is found by name among the own filters
+func TestResolveFilterByName(t *testing.T) {
+	client := FuncHTTPClient(func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, "/rest/api/3/filter/my", req.URL.Path)
+		return jsonResponse(`[{"id":"10000","name":"Open Bugs","jql":"type = Bug AND resolution IS EMPTY"}]`), nil
+	})
+
+	api := newTestAPI(t, client)
+
+	filter, err := api.ResolveFilter(context.Background(), "open bugs")
+	assert.NoError(t, err)
+	assert.Equal(t, "10000", filter.ID)
+	assert.Equal(t, "type = Bug AND resolution IS EMPTY", filter.JQL)
+}
+
+// TestResolveFilterSearchesAllFilters tests that all pages of the filter search are searched on Jira Cloud
+func TestResolveFilterSearchesAllFilters(t *testing.T) {
+	client := FuncHTTPClient(func(req *http.Request) (*http.Response, error) {
+		switch req.URL.Path {
+		case "/rest/api/3/filter/my":
+			return jsonResponse(`[]`), nil
+		case "/rest/api/3/filter/search":
+			assert.Equal(t, "team", req.URL.Query().Get("filterName"))
+			if req.URL.Query().Get("startAt") == "0" {
+				return jsonResponse(`{"startAt":0,"maxResults":1,"isLast":false,"values":[{"id":"1","name":"Team Bugs"}]}`), nil
+			}
+			return jsonResponse(`{"startAt":1,"maxResults":1,"isLast":true,"values":[{"id":"2","name":"Team","jql":"project = TEAM"}]}`), nil
+		}
+		t.Fatalf("unexpected request %s", req.URL)
+		return nil, nil
+	})
+
+	api := newTestAPI(t, client)
+
+	filter, err := api.ResolveFilter(context.Background(), "team")
+	assert.NoError(t, err)
+	assert.Equal(t, "2", filter.ID)
+	assert.Equal(t, "project = TEAM", filter.JQL)
+}
+
+// TestGetChangelogsFetchesTruncatedHistories tests that truncated changelogs are completed
+func TestGetChangelogsFetchesTruncatedHistories(t *testing.T) {
+	client := FuncHTTPClient(func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, "/rest/api/3/issue/TEST-1/changelog", req.URL.Path)
+		return jsonResponse(`{"startAt":0,"maxResults":100,"total":2,"isLast":true,"values":[
+			{"id":"1","created":"2024-01-01T10:00:00.000+0000","items":[{"field":"status","fromString":"To Do","toString":"In Progress"}]},
+			{"id":"2","created":"2024-01-02T10:00:00.000+0000","items":[{"field":"status","fromString":"In Progress","toString":"Done"},{"field":"resolution","toString":"Done"}]}
+		]}`), nil
+	})
+
+	api := newTestAPI(t, client)
+
+	issues := searchIssues(t,
+		map[string]any{"key": "TEST-1", "changelog": map[string]any{"total": 2.0, "histories": []any{}}},
+		map[string]any{"key": "TEST-2", "changelog": map[string]any{"total": 0.0, "histories": []any{}}},
+	)
+
+	entries, err := api.GetChangelogs(context.Background(), issues)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 3)
+	assert.Len(t, entries.StatusTransitions(), 2)
+	assert.Equal(t, "Done", entries.StatusTransitions()[1].ToString)
+}
+
+// TestGetIssueCommentsFollowsPagination tests that all comment pages are fetched and the bodies rendered
+func TestGetIssueCommentsFollowsPagination(t *testing.T) {
+	client := FuncHTTPClient(func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, "/rest/api/3/issue/TEST-1/comment", req.URL.Path)
+		if req.URL.Query().Get("startAt") == "0" {
+			return jsonResponse(`{"startAt":0,"maxResults":1,"total":2,"comments":[{"id":"1","body":{"type":"doc","content":[{"type":"paragraph","content":[{"type":"text","text":"First"}]}]}}]}`), nil
+		}
+		return jsonResponse(`{"startAt":1,"maxResults":1,"total":2,"comments":[{"id":"2","body":"Second","visibility":{"type":"role","value":"Developers"}}]}`), nil
+	})
+
+	api := newTestAPI(t, client)
+
+	comments, err := api.GetIssueComments(context.Background(), "TEST-1")
+	assert.NoError(t, err)
+	assert.Len(t, comments, 2)
+	assert.Equal(t, "First", comments[0].Body)
+	assert.Equal(t, "Developers", comments[1].Visibility.Value)
+}
+
+// TestDownloadAttachmentsSkipsUnchangedFiles tests the download, the filter and the deduplication
+func TestDownloadAttachmentsSkipsUnchangedFiles(t *testing.T) {
+	downloads := 0
+	client := FuncHTTPClient(func(req *http.Request) (*http.Response, error) {
+		downloads++
+		return jsonResponse("hello"), nil
+	})
+
+	api := newTestAPI(t, client, WithRateLimiter(rutil.NewRateLimiter(0, 1)))
+
+	attachments := []Attachment{
+		{IssueKey: "TEST-1", ID: "1", Filename: "notes.txt", Size: 5, MimeType: "text/plain", Content: "https://testurl.atlassian.net/attachment/1"},
+		{IssueKey: "TEST-1", ID: "2", Filename: "../image.png", Size: 5, MimeType: "image/png", Content: "https://testurl.atlassian.net/attachment/2"},
+	}
+	filter := AttachmentFilter{MimeTypes: []string{"text/*"}}
+	dir := t.TempDir()
+
+	manifest, err := api.DownloadAttachments(context.Background(), attachments, dir, filter)
+	assert.NoError(t, err)
+	assert.Equal(t, AttachmentDownloaded, manifest[0].Status)
+	assert.Equal(t, AttachmentFiltered, manifest[1].Status)
+	assert.FileExists(t, dir+"/TEST-1/notes.txt")
+
+	manifest, err = api.DownloadAttachments(context.Background(), attachments, dir, filter)
+	assert.NoError(t, err)
+	assert.Equal(t, AttachmentUnchanged, manifest[0].Status)
+	assert.Equal(t, 1, downloads)
+
+	// File names which are sanitized to the same name are kept apart
+	paths := attachmentPaths([]Attachment{
+		{IssueKey: "TEST-1", ID: "3", Filename: "a:b.png"},
+		{IssueKey: "TEST-1", ID: "4", Filename: "a?b.png"},
+		{IssueKey: "TEST-1", ID: "5", Filename: "report."},
+		{IssueKey: "TEST-1", ID: "6", Filename: "Report"},
+	})
+	assert.Equal(t, []string{"TEST-1/3-a_b.png", "TEST-1/4-a_b.png", "TEST-1/5-report", "TEST-1/6-Report"}, paths)
+}
+
+// TestGetSprintsSkipsKanbanBoards tests the agile pagination and that only scrum boards are queried
+func TestGetSprintsSkipsKanbanBoards(t *testing.T) {
+	client := FuncHTTPClient(func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, "/rest/agile/1.0/board/1/sprint", req.URL.Path)
+		if req.URL.Query().Get("startAt") == "0" {
+			return jsonResponse(`{"startAt":0,"maxResults":1,"isLast":false,"values":[{"id":10,"name":"Sprint 1","state":"closed"}]}`), nil
+		}
+		return jsonResponse(`{"startAt":1,"maxResults":1,"isLast":true,"values":[{"id":11,"name":"Sprint 2","state":"active","goal":"Ship it"}]}`), nil
+	})
+
+	api := newTestAPI(t, client)
+
+	boards := []Board{{ID: 1, Type: "scrum"}, {ID: 2, Type: "kanban"}}
+	sprints, err := api.GetSprints(context.Background(), boards)
+	assert.NoError(t, err)
+	assert.Len(t, sprints, 2)
+	assert.Equal(t, "Ship it", sprints[1].Goal)
+	assert.Equal(t, 1, sprints[1].OriginBoardID)
+}
+
+// TestGetMetadataFiltersProjects tests that only the components and versions of the selected projects are fetched
+func TestGetMetadataFiltersProjects(t *testing.T) {
+	client := FuncHTTPClient(func(req *http.Request) (*http.Response, error) {
+		switch req.URL.Path {
+		case "/rest/api/3/project/search":
+			return jsonResponse(`{"isLast":true,"values":[{"id":"1","key":"TEST","lead":{"displayName":"Jane"}},{"id":"2","key":"OTHER"}]}`), nil
+		case "/rest/api/3/project/TEST/components":
+			return jsonResponse(`[{"id":"10","name":"API","project":"TEST","lead":{"displayName":"Joe"}}]`), nil
+		case "/rest/api/3/project/TEST/versions":
+			return jsonResponse(`[{"id":"20","name":"1.0","released":true,"releaseDate":"2024-01-31"}]`), nil
+		case "/rest/api/3/statuscategory":
+			return jsonResponse(`[{"id":3,"key":"done","name":"Done"}]`), nil
+		case "/rest/api/3/issuetype", "/rest/api/3/priority", "/rest/api/3/resolution", "/rest/api/3/status":
+			return jsonResponse(`[]`), nil
+		}
+		t.Errorf("unexpected request %s", req.URL.Path)
+		return jsonResponse(`[]`), nil
+	})
+
+	api := newTestAPI(t, client)
+
+	m, err := api.GetMetadata(context.Background(), []string{"TEST"})
+	assert.NoError(t, err)
+	assert.Len(t, m.Projects, 1)
+	assert.Equal(t, "Joe", m.Components[0].Lead.DisplayName)
+	assert.Equal(t, "TEST", m.Versions[0].Project)
+	assert.Equal(t, "done", m.StatusCategories[0].Key)
+	assert.NoError(t, m.WriteCSV(t.TempDir()))
+}
+
+// TestGetUsersResolvesGroups tests the group expansion and that deleted users keep the referenced details
+func TestGetUsersResolvesGroups(t *testing.T) {
+	client := FuncHTTPClient(func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, "groups", req.URL.Query().Get("expand"))
+		if req.URL.Query().Get("accountId") == "deleted" {
+			return &http.Response{
+				StatusCode: http.StatusNotFound,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"errorMessages":["User does not exist"]}`)),
+			}, nil
+		}
+		return jsonResponse(`{"accountId":"1","displayName":"Doe, Jane","accountType":"atlassian","timeZone":"Europe/Berlin","active":true,"groups":{"size":2,"items":[{"name":"developers"},{"name":"jira-users"}]}}`), nil
+	})
+
+	api := newTestAPI(t, client)
+
+	refs := []JiraIssueUser{{AccountID: "1"}, {AccountID: "deleted", DisplayName: "Former user"}}
+	users, err := api.GetUsers(context.Background(), refs, true)
+	assert.NoError(t, err)
+	assert.Equal(t, "Doe, Jane", users[0].DisplayName)
+	assert.Equal(t, "Europe/Berlin", users[0].TimeZone)
+	assert.Equal(t, []string{"developers", "jira-users"}, users[0].Groups)
+	assert.Equal(t, "Former user", users[1].DisplayName)
+}
+
+// TestGetServiceRequestsSkipsOtherIssues tests the service desk data and that issues without a request are skipped
+func TestGetServiceRequestsSkipsOtherIssues(t *testing.T) {
+	client := FuncHTTPClient(func(req *http.Request) (*http.Response, error) {
+		switch req.URL.Path {
+		case "/rest/servicedeskapi/request/TEST-1":
+			return jsonResponse(`{"issueKey":"TEST-1","requestType":{"name":"Get IT help"}}`), nil
+		case "/rest/servicedeskapi/request/TEST-1/participant":
+			return jsonResponse(`{"isLastPage":true,"values":[{"accountId":"1","displayName":"Jane"}]}`), nil
+		case "/rest/servicedeskapi/request/TEST-1/sla":
+			return jsonResponse(`{"isLastPage":true,"values":[{"id":"1","name":"Time to resolution",
+				"ongoingCycle":{"breached":true,"goalDuration":{"millis":14400000},"remainingTime":{"millis":-60000}},
+				"completedCycles":[{"breached":false,"elapsedTime":{"millis":3600000}}]}]}`), nil
+		}
+		return &http.Response{
+			StatusCode: http.StatusNotFound,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"errorMessage":"The request could not be found"}`)),
+		}, nil
+	})
+
+	api := newTestAPI(t, client)
+
+	issues := searchIssues(t,
+		map[string]any{"key": "TEST-1", "fields": map[string]any{
+			"customfield_10002": []any{map[string]any{"id": "1", "name": "ACME"}},
+		}},
+		map[string]any{"key": "TEST-2", "fields": map[string]any{}},
+	)
+
+	requests, err := api.GetServiceRequests(context.Background(), issues, []string{"customfield_10002"})
+	assert.NoError(t, err)
+	assert.Len(t, requests, 1)
+	assert.Equal(t, "Get IT help", requests[0].RequestType)
+	assert.Equal(t, []string{"ACME"}, requests[0].Organizations)
+	assert.Equal(t, "Jane", requests[0].Participants[0].DisplayName)
+	assert.True(t, requests[0].SLAs[0].OngoingCycle.Breached)
+	assert.Len(t, requests[0].SLAs[0].CompletedCycles, 1)
+	assert.NoError(t, requests.WriteSLACSV(t.TempDir()+"/jsm-slas.csv"))
+}
+
+// TestValidateJQLReportsPositions tests the error positions and the field suggestions
+func TestValidateJQLReportsPositions(t *testing.T) {
+	client := FuncHTTPClient(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path == "/rest/api/3/jql/autocompletedata" {
+			return jsonResponse(`{"visibleFieldNames":[{"value":"status"},{"value":"statusCategory"},{"value":"summary"}]}`), nil
+		}
+		assert.Equal(t, "/rest/api/3/jql/parse", req.URL.Path)
+		assert.Equal(t, http.MethodPost, req.Method)
+		return jsonResponse(`{"queries":[{"query":"stauts = Done AND","errors":[
+			"Field 'stauts' does not exist or you do not have permission to view it.",
+			"Error in the JQL Query: Expecting a field name before the end of the query. (line 1, character 18)"]}]}`), nil
+	})
+
+	api := newTestAPI(t, client)
+
+	v, err := api.ValidateJQL(context.Background(), "stauts = Done AND")
+	assert.NoError(t, err)
+	assert.False(t, v.Valid())
+	assert.ErrorIs(t, v.Err(), ErrInvalidJQL)
+	assert.Equal(t, "stauts", v.Errors[0].UnknownField())
+	assert.Equal(t, JQLError{Message: "Error in the JQL Query: Expecting a field name before the end of the query.", Line: 1, Column: 18}, v.Errors[1])
+
+	suggestions, err := api.SuggestFields(context.Background(), "stauts")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"status"}, suggestions)
+
+	_, err = NewJiraAPI(secrets.Secrets{}, 50, WithFlavor(FlavorServer)).ValidateJQL(context.Background(), "project = TEST")
+	assert.ErrorIs(t, err, ErrValidationUnsupported)
+}
@@ -0,0 +1,287 @@
+package jira
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"jira-export/pkg/logger"
+	"jira-export/pkg/output"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Attachment is a file attached to an issue
+type Attachment struct {
+	IssueKey string        `json:"issueKey"`
+	ID       string        `json:"id"`
+	Filename string        `json:"filename"`
+	Author   JiraIssueUser `json:"author"`
+	Created  string        `json:"created"`
+	Size     int64         `json:"size"`
+	MimeType string        `json:"mimeType"`
+	Content  string        `json:"content"`
+}
+
+const (
+	// AttachmentDownloaded marks an attachment downloaded in this run
+	AttachmentDownloaded = "downloaded"
+	// AttachmentUnchanged marks an attachment that already existed with the same size and checksum
+	AttachmentUnchanged = "unchanged"
+	// AttachmentFiltered marks an attachment excluded by the size or MIME type filter
+	AttachmentFiltered = "filtered"
+)
+
+// ManifestEntry records where and how an attachment was stored
+type ManifestEntry struct {
+	Attachment
+	// Path is the path of the file relative to the attachments directory
+	Path   string `json:"path,omitempty"`
+	SHA256 string `json:"sha256,omitempty"`
+	Status string `json:"status"`
+}
+
+// AttachmentManifest lists the attachments of an export
+type AttachmentManifest []ManifestEntry
+
+// AttachmentFilter restricts the downloaded attachments
+type AttachmentFilter struct {
+	// MaxSize is the maximum size in bytes. Zero means no limit.
+	MaxSize int64
+	// MimeTypes are the allowed MIME types. A pattern may end with a wildcard,
+	// e.g. "image/*". All types are allowed if empty.
+	MimeTypes []string
+}
+
+// Match reports whether the attachment passes the filter
+func (f AttachmentFilter) Match(a Attachment) bool {
+	if f.MaxSize > 0 && a.Size > f.MaxSize {
+		return false
+	}
+	if len(f.MimeTypes) == 0 {
+		return true
+	}
+
+	mimeType := strings.ToLower(a.MimeType)
+	for _, pattern := range f.MimeTypes {
+		if ok, _ := path.Match(strings.ToLower(strings.TrimSpace(pattern)), mimeType); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// IssueAttachments returns the attachments of the issues from the "attachment" field
+func IssueAttachments(issues []SearchIssue) []Attachment {
+	attachments := []Attachment{}
+	for _, issue := range issues {
+		for _, a := range issue.Fields.Attachment {
+			a.IssueKey = issue.Key
+			attachments = append(attachments, a)
+		}
+	}
+	return attachments
+}
+
+// sanitizeFilename removes path separators and other characters that are
+// not allowed in file names, so a file name cannot escape its directory
+func sanitizeFilename(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|', 0:
+			return '_'
+		}
+		return r
+	}, name)
+	name = strings.Trim(name, " .")
+	if name == "" {
+		return "_"
+	}
+	return name
+}
+
+// attachmentPaths returns the path of every attachment relative to the
+// attachments directory: <KEY>/<filename>. If several attachments of an issue
+// have the same sanitized file name, the attachment ID is prepended to keep them apart.
+func attachmentPaths(attachments []Attachment) []string {
+	// Different file names may be sanitized to the same name, e.g. "a:b" and "a?b"
+	counts := map[string]int{}
+	for _, a := range attachments {
+		counts[a.IssueKey+"/"+strings.ToLower(sanitizeFilename(a.Filename))]++
+	}
+
+	paths := make([]string, len(attachments))
+	for n, a := range attachments {
+		name := sanitizeFilename(a.Filename)
+		if counts[a.IssueKey+"/"+strings.ToLower(name)] > 1 {
+			name = a.ID + "-" + name
+		}
+		paths[n] = filepath.Join(sanitizeFilename(a.IssueKey), name)
+	}
+	return paths
+}
+
+// ReadAttachmentManifest reads the manifest of a previous run. A missing manifest is not an error.
+func ReadAttachmentManifest(filename string) (AttachmentManifest, error) {
+	data, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return AttachmentManifest{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading manifest: %v", err)
+	}
+
+	var manifest AttachmentManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("error decoding manifest: %v", err)
+	}
+	return manifest, nil
+}
+
+// fileChecksum returns the size and the SHA-256 checksum of a file
+func fileChecksum(filename string) (int64, string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return 0, "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return 0, "", err
+	}
+	return size, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// DownloadAttachments downloads the attachments matching the filter into
+// dir/<KEY>/ using Concurrency parallel downloads. Files that already exist
+// with the expected size and the checksum recorded in manifest.json by a
+// previous run are skipped. The manifest of this run is written to dir/manifest.json.
+func (j JiraAPI) DownloadAttachments(ctx context.Context, attachments []Attachment, dir string, filter AttachmentFilter) (AttachmentManifest, error) {
+	manifestFile := filepath.Join(dir, "manifest.json")
+	previous, err := ReadAttachmentManifest(manifestFile)
+	if err != nil {
+		return nil, err
+	}
+	checksums := map[string]string{}
+	for _, e := range previous {
+		checksums[e.ID] = e.SHA256
+	}
+
+	paths := attachmentPaths(attachments)
+	manifest := make(AttachmentManifest, len(attachments))
+
+	err = runPool(ctx, len(attachments), j.Concurrency, func(ctx context.Context, i int) error {
+		a := attachments[i]
+		entry := ManifestEntry{Attachment: a, Status: AttachmentFiltered}
+		if !filter.Match(a) {
+			manifest[i] = entry
+			return nil
+		}
+
+		entry.Path = paths[i]
+		filename := filepath.Join(dir, paths[i])
+
+		// Skip files which have not changed since the previous run
+		if size, checksum, err := fileChecksum(filename); err == nil && size == a.Size {
+			if previousChecksum := checksums[a.ID]; previousChecksum == "" || previousChecksum == checksum {
+				entry.SHA256 = checksum
+				entry.Status = AttachmentUnchanged
+				manifest[i] = entry
+				return nil
+			}
+		}
+
+		checksum, err := j.downloadFile(ctx, a.Content, filename)
+		if err != nil {
+			return fmt.Errorf("error downloading attachment %s of %s: %w", a.Filename, a.IssueKey, err)
+		}
+		logger.Logger.Debug("Downloaded attachment", "key", a.IssueKey, "filename", a.Filename, "size", a.Size)
+
+		entry.SHA256 = checksum
+		entry.Status = AttachmentDownloaded
+		manifest[i] = entry
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := output.WriteJSON(manifestFile, manifest); err != nil {
+		return nil, fmt.Errorf("error writing manifest: %v", err)
+	}
+	return manifest, nil
+}
+
+// idleReader resets the timer on every read, so a stalled download is
+// cancelled by the timer while a slow but progressing download is not
+type idleReader struct {
+	r       io.Reader
+	timer   *time.Timer
+	timeout time.Duration
+}
+
+func (r idleReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.timer.Reset(r.timeout)
+	return n, err
+}
+
+// downloadFile downloads the url into the file and returns its SHA-256 checksum.
+// The content is written to a temporary file first, so an interrupted
+// download never leaves a truncated file behind. A download may take longer
+// than the RequestTimeout, but it is cancelled if no data is received for
+// the RequestTimeout.
+func (j JiraAPI) downloadFile(ctx context.Context, url string, filename string) (string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	req, err := makeRequest(ctx, url, j.secrets)
+	if err != nil {
+		return "", fmt.Errorf("error preparing GET request: %v", err)
+	}
+	req.Header.Set("Accept", "*/*")
+
+	resp, err := j.retry(ctx, req, j.sendDownloadRequest)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body := io.Reader(resp.Body)
+	if j.RequestTimeout > 0 {
+		timer := time.AfterFunc(j.RequestTimeout, cancel)
+		defer timer.Stop()
+		body = idleReader{r: resp.Body, timer: timer, timeout: j.RequestTimeout}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return "", fmt.Errorf("error creating directory: %v", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(filename), ".download-*")
+	if err != nil {
+		return "", fmt.Errorf("error creating file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tmp, hash), body); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("error writing file: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("error writing file: %v", err)
+	}
+
+	if err := os.Rename(tmp.Name(), filename); err != nil {
+		return "", fmt.Errorf("error renaming file: %v", err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
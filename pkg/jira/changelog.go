@@ -0,0 +1,203 @@
+package jira
+
+import (
+	"context"
+	"fmt"
+	"jira-export/pkg/output"
+	"net/url"
+	"strconv"
+)
+
+// Changelog is the history of an issue as returned by the search with expand=changelog
+type Changelog struct {
+	StartAt    int                `json:"startAt"`
+	MaxResults int                `json:"maxResults"`
+	Total      int                `json:"total"`
+	Histories  []ChangelogHistory `json:"histories"`
+}
+
+// ChangelogHistory is a set of field changes made by a user at the same time
+type ChangelogHistory struct {
+	ID      string          `json:"id"`
+	Author  JiraIssueUser   `json:"author"`
+	Created string          `json:"created"`
+	Items   []ChangelogItem `json:"items"`
+}
+
+// ChangelogItem is the change of a single field
+type ChangelogItem struct {
+	Field      string `json:"field"`
+	FieldType  string `json:"fieldtype"`
+	FieldID    string `json:"fieldId,omitempty"`
+	From       string `json:"from"`
+	FromString string `json:"fromString"`
+	To         string `json:"to"`
+	ToString   string `json:"toString"`
+}
+
+// changelogPage is a page of the issue changelog endpoint of Jira Cloud
+type changelogPage struct {
+	StartAt    int                `json:"startAt"`
+	MaxResults int                `json:"maxResults"`
+	Total      int                `json:"total"`
+	IsLast     bool               `json:"isLast"`
+	Values     []ChangelogHistory `json:"values"`
+}
+
+// ChangelogEntry is a single field change of an issue
+type ChangelogEntry struct {
+	IssueKey   string `json:"issueKey"`
+	HistoryID  string `json:"historyId"`
+	Author     string `json:"author"`
+	Created    string `json:"created"`
+	Field      string `json:"field"`
+	FieldID    string `json:"fieldId,omitempty"`
+	FieldType  string `json:"fieldType"`
+	From       string `json:"from"`
+	FromString string `json:"fromString"`
+	To         string `json:"to"`
+	ToString   string `json:"toString"`
+	// author is the user who made the change, see ChangelogEntries.Users
+	author JiraIssueUser
+}
+
+// ChangelogEntries contains one entry per field change
+type ChangelogEntries []ChangelogEntry
+
+// GetChangelogs returns the field changes of the issues. The issues must
+// have been searched with expand=changelog. If the changelog of an issue is
+// truncated, the remaining histories are fetched from the issue changelog endpoint.
+func (j JiraAPI) GetChangelogs(ctx context.Context, issues []SearchIssue) (ChangelogEntries, error) {
+	keys := make([]string, len(issues))
+	changelogs := make([][]ChangelogHistory, len(issues))
+	incomplete := []int{}
+
+	for n, issue := range issues {
+		keys[n] = issue.Key
+
+		changelog := Changelog{}
+		if issue.Changelog != nil {
+			changelog = *issue.Changelog
+		}
+		changelogs[n] = changelog.Histories
+
+		if changelog.Total > len(changelog.Histories) {
+			incomplete = append(incomplete, n)
+		}
+	}
+
+	// Fetch the complete changelogs of the truncated issues
+	err := runPool(ctx, len(incomplete), j.Concurrency, func(ctx context.Context, i int) error {
+		n := incomplete[i]
+		histories, err := j.GetIssueChangelog(ctx, keys[n])
+		if err != nil {
+			return err
+		}
+		changelogs[n] = histories
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	entries := ChangelogEntries{}
+	for n, histories := range changelogs {
+		entries = append(entries, changelogEntries(keys[n], histories)...)
+	}
+	return entries, nil
+}
+
+// Users returns the authors of the changes
+func (e ChangelogEntries) Users() []JiraIssueUser {
+	users := []JiraIssueUser{}
+	for _, entry := range e {
+		users = append(users, entry.author)
+	}
+	return UniqueUsers(users)
+}
+
+// GetIssueChangelog returns all histories of the changelog of an issue
+func (j JiraAPI) GetIssueChangelog(ctx context.Context, key string) ([]ChangelogHistory, error) {
+	// Jira Server has no changelog endpoint, but returns the complete changelog on the issue
+	if j.Flavor == FlavorServer {
+		var issue struct {
+			Changelog Changelog `json:"changelog"`
+		}
+		u := j.apiURL("/issue/" + url.PathEscape(key) + "?expand=changelog&fields=none")
+		if err := j.getJSON(ctx, u, &issue); err != nil {
+			return nil, fmt.Errorf("error getting changelog of %s: %w", key, err)
+		}
+		return issue.Changelog.Histories, nil
+	}
+
+	histories := []ChangelogHistory{}
+	for startAt := 0; ; {
+		u := fmt.Sprintf("%s?startAt=%s&maxResults=100", j.apiURL("/issue/"+url.PathEscape(key)+"/changelog"), strconv.Itoa(startAt))
+
+		var page changelogPage
+		if err := j.getJSON(ctx, u, &page); err != nil {
+			return nil, fmt.Errorf("error getting changelog of %s: %w", key, err)
+		}
+		histories = append(histories, page.Values...)
+		startAt += len(page.Values)
+
+		if page.IsLast || len(page.Values) == 0 || startAt >= page.Total {
+			return histories, nil
+		}
+	}
+}
+
+// changelogEntries flattens the histories of an issue into one entry per field change
+func changelogEntries(key string, histories []ChangelogHistory) ChangelogEntries {
+	entries := ChangelogEntries{}
+	for _, h := range histories {
+		for _, item := range h.Items {
+			entries = append(entries, ChangelogEntry{
+				IssueKey:   key,
+				HistoryID:  h.ID,
+				Author:     h.Author.DisplayName,
+				author:     h.Author,
+				Created:    h.Created,
+				Field:      item.Field,
+				FieldID:    item.FieldID,
+				FieldType:  item.FieldType,
+				From:       item.From,
+				FromString: item.FromString,
+				To:         item.To,
+				ToString:   item.ToString,
+			})
+		}
+	}
+	return entries
+}
+
+// StatusTransitions returns the changes of the status field
+func (c ChangelogEntries) StatusTransitions() ChangelogEntries {
+	transitions := ChangelogEntries{}
+	for _, e := range c {
+		if e.Field == "status" {
+			transitions = append(transitions, e)
+		}
+	}
+	return transitions
+}
+
+// WriteCSV writes one row per field change to a CSV file
+func (c ChangelogEntries) WriteCSV(filename string) error {
+	header := []string{"key", "historyId", "author", "created", "field", "fieldId", "fieldType", "from", "fromString", "to", "toString"}
+	rows := make([][]string, 0, len(c))
+	for _, e := range c {
+		rows = append(rows, []string{e.IssueKey, e.HistoryID, e.Author, e.Created, e.Field, e.FieldID, e.FieldType, e.From, e.FromString, e.To, e.ToString})
+	}
+	return output.WriteCSV(filename, header, rows)
+}
+
+// WriteTransitionsCSV writes one row per status transition to a CSV file
+func (c ChangelogEntries) WriteTransitionsCSV(filename string) error {
+	header := []string{"key", "author", "created", "fromStatus", "toStatus"}
+	rows := [][]string{}
+	for _, e := range c.StatusTransitions() {
+		rows = append(rows, []string{e.IssueKey, e.Author, e.Created, e.FromString, e.ToString})
+	}
+	return output.WriteCSV(filename, header, rows)
+}
@@ -0,0 +1,142 @@
+package jira
+
+import (
+	"context"
+	"fmt"
+	"jira-export/pkg/output"
+	"net/url"
+	"strconv"
+)
+
+// Comment is a comment of an issue
+type Comment struct {
+	IssueKey     string        `json:"issueKey"`
+	ID           string        `json:"id"`
+	Author       JiraIssueUser `json:"author"`
+	UpdateAuthor JiraIssueUser `json:"updateAuthor"`
+	Body         string        `json:"body"`
+	Created      string        `json:"created"`
+	Updated      string        `json:"updated"`
+	// Visibility restricts the comment to a group or project role
+	Visibility *CommentVisibility `json:"visibility,omitempty"`
+	// JSDPublic is false for internal comments of Jira Service Management
+	JSDPublic *bool `json:"jsdPublic,omitempty"`
+}
+
+// CommentVisibility describes the group or role a comment is restricted to
+type CommentVisibility struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// rawComment is a comment as returned by Jira with the body as ADF or wiki markup
+type rawComment struct {
+	Comment
+	Body any `json:"body"`
+}
+
+// commentPage is a page of the issue comment endpoint
+type commentPage struct {
+	StartAt    int          `json:"startAt"`
+	MaxResults int          `json:"maxResults"`
+	Total      int          `json:"total"`
+	Comments   []rawComment `json:"comments"`
+}
+
+// Comments contains the comments of several issues
+type Comments []Comment
+
+// GetComments returns the comments of the issues with the keys. The issues
+// are processed in parallel and the comments are returned in issue order.
+func (j JiraAPI) GetComments(ctx context.Context, keys []string) (Comments, error) {
+	comments := make([]Comments, len(keys))
+	err := runPool(ctx, len(keys), j.Concurrency, func(ctx context.Context, i int) error {
+		c, err := j.GetIssueComments(ctx, keys[i])
+		if err != nil {
+			return err
+		}
+		comments[i] = c
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	all := Comments{}
+	for _, c := range comments {
+		all = append(all, c...)
+	}
+	return all, nil
+}
+
+// GetIssueComments returns all comments of an issue following the pagination
+func (j JiraAPI) GetIssueComments(ctx context.Context, key string) (Comments, error) {
+	comments := Comments{}
+	for startAt := 0; ; {
+		u := fmt.Sprintf("%s?startAt=%s&maxResults=100&orderBy=created", j.apiURL("/issue/"+url.PathEscape(key)+"/comment"), strconv.Itoa(startAt))
+
+		var page commentPage
+		if err := j.getJSON(ctx, u, &page); err != nil {
+			return nil, fmt.Errorf("error getting comments of %s: %w", key, err)
+		}
+
+		for _, c := range page.Comments {
+			comment := c.Comment
+			comment.IssueKey = key
+			comment.Body = renderRichText(c.Body)
+			comments = append(comments, comment)
+		}
+		startAt += len(page.Comments)
+
+		if len(page.Comments) == 0 || startAt >= page.Total {
+			return comments, nil
+		}
+	}
+}
+
+// ByIssue returns the comments keyed by issue key
+func (c Comments) ByIssue() map[string]Comments {
+	byIssue := map[string]Comments{}
+	for _, comment := range c {
+		byIssue[comment.IssueKey] = append(byIssue[comment.IssueKey], comment)
+	}
+	return byIssue
+}
+
+// Users returns the authors and update authors of the comments
+func (c Comments) Users() []JiraIssueUser {
+	users := []JiraIssueUser{}
+	for _, comment := range c {
+		users = append(users, comment.Author, comment.UpdateAuthor)
+	}
+	return UniqueUsers(users)
+}
+
+// WriteCSV writes one row per comment to a CSV file
+func (c Comments) WriteCSV(filename string) error {
+	header := []string{"key", "id", "author", "created", "updated", "updateAuthor", "visibilityType", "visibilityValue", "public", "body"}
+	rows := make([][]string, 0, len(c))
+	for _, comment := range c {
+		visibilityType, visibilityValue := "", ""
+		if comment.Visibility != nil {
+			visibilityType, visibilityValue = comment.Visibility.Type, comment.Visibility.Value
+		}
+		public := ""
+		if comment.JSDPublic != nil {
+			public = strconv.FormatBool(*comment.JSDPublic)
+		}
+		rows = append(rows, []string{
+			comment.IssueKey,
+			comment.ID,
+			comment.Author.DisplayName,
+			comment.Created,
+			comment.Updated,
+			comment.UpdateAuthor.DisplayName,
+			visibilityType,
+			visibilityValue,
+			public,
+			comment.Body,
+		})
+	}
+	return output.WriteCSV(filename, header, rows)
+}
@@ -0,0 +1,100 @@
+package jira
+
+import (
+	"fmt"
+	"jira-export/pkg/adf"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// NameCustomFields replaces the field IDs of the custom fields by their names.
+// If several custom fields share a name, the field ID is appended to the name
+// to keep the keys unique.
+func (i Issues) NameCustomFields(fieldMap FieldMap) {
+	// Count the custom fields per name to detect ambiguous names
+	ids := map[string]bool{}
+	for _, issue := range i {
+		for id := range issue.CustomFields {
+			ids[id] = true
+		}
+	}
+	counts := map[string]int{}
+	for id := range ids {
+		if name, ok := fieldMap.NameByID(id); ok {
+			counts[name]++
+		}
+	}
+
+	for n := range i {
+		named := make(map[string]any, len(i[n].CustomFields))
+		for id, value := range i[n].CustomFields {
+			name, ok := fieldMap.NameByID(id)
+			switch {
+			case !ok:
+				name = id
+			case counts[name] > 1:
+				name = fmt.Sprintf("%s (%s)", name, id)
+			}
+			named[name] = value
+		}
+		if len(named) > 0 {
+			i[n].CustomFields = named
+		}
+	}
+}
+
+// customFieldNames returns the sorted keys of the custom fields of all issues
+func (i Issues) customFieldNames() []string {
+	seen := map[string]bool{}
+	names := []string{}
+	for _, issue := range i {
+		for name := range issue.CustomFields {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CustomFieldString converts the raw value of a custom field to a string.
+// Options, users and other objects are represented by their value or name,
+// arrays are joined with "|" and rich text is converted to Markdown.
+func CustomFieldString(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s := CustomFieldString(item); s != "" {
+				values = append(values, s)
+			}
+		}
+		return strings.Join(values, "|")
+	case map[string]any:
+		// Rich text fields are stored as Atlassian Document Format
+		if adf.IsDocument(v) {
+			return renderRichText(v)
+		}
+		for _, key := range []string{"value", "name", "displayName", "key", "id"} {
+			if s, ok := v[key].(string); ok {
+				// Cascading selects contain the selected child option
+				if child, ok := v["child"].(map[string]any); ok {
+					return s + " - " + CustomFieldString(child)
+				}
+				return s
+			}
+		}
+	}
+	return fmt.Sprintf("%v", value)
+}
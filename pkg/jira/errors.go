@@ -0,0 +1,104 @@
+package jira
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+var (
+	// ErrUnauthorized is returned if the credentials are rejected (401)
+	ErrUnauthorized = errors.New("authentication failed")
+	// ErrForbidden is returned if the user lacks the permission for a resource (403)
+	ErrForbidden = errors.New("permission denied")
+	// ErrNotFound is returned if a resource does not exist (404)
+	ErrNotFound = errors.New("not found")
+	// ErrInvalidJQL is returned if Jira rejects the search query (400 on a search
+	// or JQL request) or the JQL validation fails
+	ErrInvalidJQL = errors.New("invalid JQL query")
+	// ErrRateLimited is returned if the request was still throttled after all attempts (429)
+	ErrRateLimited = errors.New("rate limited")
+)
+
+// APIError is returned for an unsuccessful response from Jira. It carries the
+// messages of the Jira error body and unwraps to one of the Err* sentinel errors,
+// so it can be checked with errors.Is.
+type APIError struct {
+	StatusCode      int               `json:"-"`
+	URL             string            `json:"-"`
+	ErrorMessages   []string          `json:"errorMessages,omitempty"`
+	WarningMessages []string          `json:"warningMessages,omitempty"`
+	Errors          map[string]string `json:"errors,omitempty"`
+	kind            error
+}
+
+// jqlEndpointPattern matches the endpoints which report an invalid JQL query
+// with a bad request: the legacy and the enhanced search and the JQL parser.
+// Other endpoints ending in /search, like /filter/search, are not matched.
+var jqlEndpointPattern = regexp.MustCompile(`/rest/api/[^/]+/(search|search/jql|jql/parse)$`)
+
+// newAPIError creates an APIError from the response to the request and closes the response body
+func newAPIError(req *http.Request, resp *http.Response) *APIError {
+	defer resp.Body.Close()
+
+	apiErr := &APIError{StatusCode: resp.StatusCode, URL: req.URL.Redacted()}
+
+	// The body is not JSON for every error (e.g. HTML error pages), so
+	// decoding errors are ignored
+	if body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20)); err == nil {
+		json.Unmarshal(body, apiErr)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		apiErr.kind = ErrUnauthorized
+	case http.StatusForbidden:
+		apiErr.kind = ErrForbidden
+	case http.StatusNotFound:
+		apiErr.kind = ErrNotFound
+	case http.StatusTooManyRequests:
+		apiErr.kind = ErrRateLimited
+	case http.StatusBadRequest:
+		if jqlEndpointPattern.MatchString(req.URL.Path) {
+			apiErr.kind = ErrInvalidJQL
+		}
+	}
+
+	return apiErr
+}
+
+// Error returns the status code and the messages of the Jira response
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("jira responded with %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	if e.kind != nil {
+		msg = fmt.Sprintf("%s: %s", e.kind, msg)
+		// Errors found in a successful response, e.g. by the JQL validation, have no status
+		if e.StatusCode == 0 {
+			msg = e.kind.Error()
+		}
+	}
+
+	messages := e.Messages()
+	if len(messages) > 0 {
+		msg = fmt.Sprintf("%s: %s", msg, strings.Join(messages, "; "))
+	}
+	return msg
+}
+
+// Messages returns the error messages and the field errors of the response
+func (e *APIError) Messages() []string {
+	messages := append([]string{}, e.ErrorMessages...)
+	for field, message := range e.Errors {
+		messages = append(messages, fmt.Sprintf("%s: %s", field, message))
+	}
+	return messages
+}
+
+// Unwrap returns the sentinel error matching the status code
+func (e *APIError) Unwrap() error {
+	return e.kind
+}
@@ -0,0 +1,216 @@
+package jira
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Field describes a system or custom field of Jira
+type Field struct {
+	ID          string       `json:"id"`
+	Key         string       `json:"key,omitempty"`
+	Name        string       `json:"name"`
+	Custom      bool         `json:"custom"`
+	Navigable   bool         `json:"navigable"`
+	Searchable  bool         `json:"searchable"`
+	Orderable   bool         `json:"orderable"`
+	ClauseNames []string     `json:"clauseNames,omitempty"`
+	Schema      *FieldSchema `json:"schema,omitempty"`
+}
+
+// FieldSchema describes the type of the values of a field
+type FieldSchema struct {
+	Type     string `json:"type"`
+	Items    string `json:"items,omitempty"`
+	System   string `json:"system,omitempty"`
+	Custom   string `json:"custom,omitempty"`
+	CustomID int    `json:"customId,omitempty"`
+}
+
+// TypeName returns a short description of the field type, e.g. "array<string>"
+func (f Field) TypeName() string {
+	if f.Schema == nil {
+		return ""
+	}
+	if f.Schema.Items != "" {
+		return fmt.Sprintf("%s<%s>", f.Schema.Type, f.Schema.Items)
+	}
+	return f.Schema.Type
+}
+
+// SchemaName returns the system or custom schema of the field, e.g.
+// "com.pyxis.greenhopper.jira:gh-sprint"
+func (f Field) SchemaName() string {
+	if f.Schema == nil {
+		return ""
+	}
+	if f.Schema.Custom != "" {
+		return f.Schema.Custom
+	}
+	return f.Schema.System
+}
+
+// fieldCache keeps the field metadata in memory, so it is only fetched once per JiraAPI
+type fieldCache struct {
+	mu     sync.Mutex
+	fields []Field
+}
+
+// GetFields returns the system and custom fields of the Jira instance.
+// The result is cached for the lifetime of the JiraAPI object.
+func (j JiraAPI) GetFields(ctx context.Context) ([]Field, error) {
+	j.fieldCache.mu.Lock()
+	defer j.fieldCache.mu.Unlock()
+
+	if j.fieldCache.fields != nil {
+		return j.fieldCache.fields, nil
+	}
+
+	var fields []Field
+	if err := j.getJSON(ctx, j.apiURL("/field"), &fields); err != nil {
+		return nil, fmt.Errorf("error getting fields: %w", err)
+	}
+
+	sort.Slice(fields, func(a, b int) bool {
+		return fields[a].ID < fields[b].ID
+	})
+	j.fieldCache.fields = fields
+
+	return fields, nil
+}
+
+// GetFieldMap returns a FieldMap of the fields of the Jira instance
+func (j JiraAPI) GetFieldMap(ctx context.Context) (FieldMap, error) {
+	fields, err := j.GetFields(ctx)
+	if err != nil {
+		return FieldMap{}, err
+	}
+	return NewFieldMap(fields), nil
+}
+
+// FieldMap translates between field IDs and field names in both directions.
+// Lookups are case-insensitive.
+type FieldMap struct {
+	byID   map[string]Field
+	byName map[string]Field
+}
+
+// NewFieldMap creates a FieldMap from the fields. If several fields share a
+// name, the name resolves to the first field.
+func NewFieldMap(fields []Field) FieldMap {
+	m := FieldMap{
+		byID:   make(map[string]Field, len(fields)),
+		byName: make(map[string]Field, len(fields)),
+	}
+	for _, f := range fields {
+		m.byID[strings.ToLower(f.ID)] = f
+		if _, ok := m.byName[strings.ToLower(f.Name)]; !ok {
+			m.byName[strings.ToLower(f.Name)] = f
+		}
+	}
+	return m
+}
+
+// Field returns the field with the ID
+func (m FieldMap) Field(id string) (Field, bool) {
+	f, ok := m.byID[strings.ToLower(id)]
+	return f, ok
+}
+
+// NameByID returns the name of the field with the ID
+func (m FieldMap) NameByID(id string) (string, bool) {
+	f, ok := m.Field(id)
+	return f.Name, ok
+}
+
+// IDByName returns the ID of the field with the name
+func (m FieldMap) IDByName(name string) (string, bool) {
+	f, ok := m.byName[strings.ToLower(name)]
+	return f.ID, ok
+}
+
+// Resolve returns the ID of a field given by ID or name. IDs take precedence over names.
+func (m FieldMap) Resolve(idOrName string) (string, bool) {
+	if f, ok := m.Field(idOrName); ok {
+		return f.ID, true
+	}
+	return m.IDByName(idOrName)
+}
+
+// FieldsBySchema returns the IDs of the custom fields with the custom schema,
+// e.g. "com.pyxis.greenhopper.jira:gh-sprint"
+func (m FieldMap) FieldsBySchema(schema string) []string {
+	ids := []string{}
+	for _, f := range m.byID {
+		if f.Schema != nil && f.Schema.Custom == schema {
+			ids = append(ids, f.ID)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// ResolveFieldIDs translates field names to field IDs. Each entry can be a
+// field ID (e.g. "customfield_10016") or a field name (e.g. "Story Points"),
+// both matched case-insensitively. Special values like "*all", "*navigable"
+// or "-comment" are passed through unchanged. A selection of exclusions only
+// is completed with "*navigable", as Jira would otherwise return no fields.
+func (j JiraAPI) ResolveFieldIDs(ctx context.Context, names []string) ([]string, error) {
+	fieldMap, err := j.GetFieldMap(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		// The issue properties are always returned and Jira ignores them as fields
+		if strings.HasPrefix(name, "*") || strings.HasPrefix(name, "-") || isIssueProperty(name) {
+			ids = append(ids, name)
+			continue
+		}
+
+		id, ok := fieldMap.Resolve(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown field %q", name)
+		}
+		ids = append(ids, id)
+	}
+
+	if len(ids) > 0 && !slices.ContainsFunc(ids, func(id string) bool { return !strings.HasPrefix(id, "-") }) {
+		ids = append([]string{navigableFields}, ids...)
+	}
+	return ids, nil
+}
+
+// IsFieldSubset reports whether the field selection lists the returned fields
+// rather than selecting all fields with a wildcard or exclusions only.
+// Fields needed by an export only have to be added to such a selection.
+func IsFieldSubset(fields []string) bool {
+	subset := false
+	for _, f := range fields {
+		if strings.HasPrefix(f, "*") {
+			return false
+		}
+		if !strings.HasPrefix(f, "-") {
+			subset = true
+		}
+	}
+	return subset
+}
+
+// isIssueProperty reports whether the name is a property of every issue rather than a field
+func isIssueProperty(name string) bool {
+	switch strings.ToLower(name) {
+	case "id", "key", "self":
+		return true
+	}
+	return false
+}
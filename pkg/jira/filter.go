@@ -0,0 +1,99 @@
+package jira
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Filter is a saved Jira filter
+type Filter struct {
+	ID          string        `json:"id"`
+	Name        string        `json:"name"`
+	Description string        `json:"description,omitempty"`
+	JQL         string        `json:"jql"`
+	Owner       JiraIssueUser `json:"owner"`
+	Favourite   bool          `json:"favourite"`
+	ViewURL     string        `json:"viewUrl,omitempty"`
+	SearchURL   string        `json:"searchUrl,omitempty"`
+}
+
+// GetFilter returns the filter with the ID
+func (j JiraAPI) GetFilter(ctx context.Context, id string) (filter Filter, err error) {
+	if err := j.getJSON(ctx, j.apiURL("/filter/"+url.PathEscape(id)), &filter); err != nil {
+		return filter, fmt.Errorf("error getting filter %s: %w", id, err)
+	}
+	return filter, nil
+}
+
+// GetFavouriteFilters returns the favourite filters of the user
+func (j JiraAPI) GetFavouriteFilters(ctx context.Context) ([]Filter, error) {
+	var filters []Filter
+	if err := j.getJSON(ctx, j.apiURL("/filter/favourite"), &filters); err != nil {
+		return nil, fmt.Errorf("error getting favourite filters: %w", err)
+	}
+	return filters, nil
+}
+
+// GetMyFilters returns the filters owned by the user including the favourite filters
+func (j JiraAPI) GetMyFilters(ctx context.Context) ([]Filter, error) {
+	var filters []Filter
+	if err := j.getJSON(ctx, j.apiURL("/filter/my?includeFavourites=true"), &filters); err != nil {
+		return nil, fmt.Errorf("error getting own filters: %w", err)
+	}
+	return filters, nil
+}
+
+// searchFilters returns the filters visible to the user whose name contains the name (Jira Cloud only)
+func (j JiraAPI) searchFilters(ctx context.Context, name string) ([]Filter, error) {
+	q := url.Values{}
+	q.Set("filterName", name)
+	q.Set("expand", "jql,owner,favourite,viewUrl")
+
+	filters, err := getPagedValues[Filter](ctx, j, j.apiURL("/filter/search"), q)
+	if err != nil {
+		return nil, fmt.Errorf("error searching filters: %w", err)
+	}
+	return filters, nil
+}
+
+// ResolveFilter returns the filter given by ID or name. Names are matched
+// case-insensitively against the favourite and owned filters first, on Jira
+// Cloud all filters visible to the user are searched afterwards.
+func (j JiraAPI) ResolveFilter(ctx context.Context, idOrName string) (Filter, error) {
+	if _, err := strconv.Atoi(idOrName); err == nil {
+		return j.GetFilter(ctx, idOrName)
+	}
+
+	filters, err := j.GetMyFilters(ctx)
+	if err != nil {
+		return Filter{}, err
+	}
+	if f, ok := findFilter(filters, idOrName); ok {
+		return f, nil
+	}
+
+	if j.Flavor != FlavorServer {
+		filters, err := j.searchFilters(ctx, idOrName)
+		if err != nil {
+			return Filter{}, err
+		}
+		if f, ok := findFilter(filters, idOrName); ok {
+			return f, nil
+		}
+	}
+
+	return Filter{}, fmt.Errorf("filter %q: %w", idOrName, ErrNotFound)
+}
+
+// findFilter returns the filter with the name
+func findFilter(filters []Filter, name string) (Filter, bool) {
+	for _, f := range filters {
+		if strings.EqualFold(f.Name, name) {
+			return f, true
+		}
+	}
+	return Filter{}, false
+}
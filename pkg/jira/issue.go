@@ -3,15 +3,140 @@ package jira
 import (
 	"encoding/csv"
 	"fmt"
+	"jira-export/pkg/adf"
+	"jira-export/pkg/logger"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // Issues
 type Issues []Issue
 
-// WriteCSV writes the Issues to a CSV file
-func (i *Issues) WriteCSV(filename string) error {
+// csvColumn maps a CSV column to the Jira field it is derived from
+type csvColumn struct {
+	name  string
+	field string
+	value func(issue Issue, o CSVOptions) string
+}
+
+// CSVOptions controls the CSV export of the issues
+type CSVOptions struct {
+	// Fields are the IDs of the selected fields, all columns are written if empty
+	Fields []string
+	// DateFormat is a named date format or a Go time layout, see DateLayout
+	DateFormat string
+	// Now is the reference time of the derived durations, the current time if not set
+	Now time.Time
+}
+
+// formatTime formats the time with the date format of the options
+func (o CSVOptions) formatTime(t Time) string {
+	return t.Format(DateLayout(o.DateFormat))
+}
+
+// csvColumns are the columns of the CSV export in order
+var csvColumns = []csvColumn{
+	{"key", "key", func(i Issue, o CSVOptions) string { return i.Key }},
+	{"reporter", "reporter", func(i Issue, o CSVOptions) string { return i.Reporter.DisplayName }},
+	{"assignee", "assignee", func(i Issue, o CSVOptions) string { return i.Assignee.DisplayName }},
+	{"creator", "creator", func(i Issue, o CSVOptions) string { return i.Creator.DisplayName }},
+	{"title", "summary", func(i Issue, o CSVOptions) string { return i.Title }},
+	{"components", "components", func(i Issue, o CSVOptions) string { return strings.Join(i.Components, "|") }},
+	{"status", "status", func(i Issue, o CSVOptions) string { return i.Status }},
+	{"issuetype", "issuetype", func(i Issue, o CSVOptions) string { return i.IssueType }},
+	{"resolutiondate", "resolutiondate", func(i Issue, o CSVOptions) string { return o.formatTime(i.ResolutionDate) }},
+	{"updated", "updated", func(i Issue, o CSVOptions) string { return o.formatTime(i.Updated) }},
+	{"created", "created", func(i Issue, o CSVOptions) string { return o.formatTime(i.Created) }},
+	{"statusCategoryChangeDate", "statuscategorychangedate", func(i Issue, o CSVOptions) string { return o.formatTime(i.StatusCategoryChangeDate) }},
+	{"parent", "parent", func(i Issue, o CSVOptions) string { return i.Parent }},
+	{"epic", "parent", func(i Issue, o CSVOptions) string { return i.Epic }},
+	{"subtasks", "subtasks", func(i Issue, o CSVOptions) string {
+		keys := make([]string, len(i.Subtasks))
+		for n, s := range i.Subtasks {
+			keys[n] = s.Key
+		}
+		return strings.Join(keys, "|")
+	}},
+	{"projectKey", "project", func(i Issue, o CSVOptions) string { return i.ProjectKey }},
+	{"projectName", "project", func(i Issue, o CSVOptions) string { return i.ProjectName }},
+	{"statusCategory", "status", func(i Issue, o CSVOptions) string { return i.StatusCategory }},
+	{"priority", "priority", func(i Issue, o CSVOptions) string { return i.Priority }},
+	{"resolution", "resolution", func(i Issue, o CSVOptions) string { return i.Resolution }},
+	{"labels", "labels", func(i Issue, o CSVOptions) string { return strings.Join(i.Labels, "|") }},
+	{"fixVersions", "fixversions", func(i Issue, o CSVOptions) string { return strings.Join(i.FixVersions, "|") }},
+	{"affectedVersions", "versions", func(i Issue, o CSVOptions) string { return strings.Join(i.AffectedVersions, "|") }},
+	{"duedate", "duedate", func(i Issue, o CSVOptions) string { return i.DueDate }},
+	{"environment", "environment", func(i Issue, o CSVOptions) string { return i.Environment }},
+	{"originalEstimateSeconds", "timeoriginalestimate", func(i Issue, o CSVOptions) string {
+		return timeTrackingString(i.TimeTracking, func(t TimeTracking) int { return t.OriginalEstimateSeconds })
+	}},
+	{"remainingEstimateSeconds", "timeestimate", func(i Issue, o CSVOptions) string {
+		return timeTrackingString(i.TimeTracking, func(t TimeTracking) int { return t.RemainingEstimateSeconds })
+	}},
+	{"timeSpentSeconds", "timespent", func(i Issue, o CSVOptions) string {
+		return timeTrackingString(i.TimeTracking, func(t TimeTracking) int { return t.TimeSpentSeconds })
+	}},
+	{"votes", "votes", func(i Issue, o CSVOptions) string { return strconv.Itoa(i.Votes) }},
+	{"watchCount", "watches", func(i Issue, o CSVOptions) string { return strconv.Itoa(i.WatchCount) }},
+	{"securityLevel", "security", func(i Issue, o CSVOptions) string { return i.SecurityLevel }},
+	{"ageDays", "created", func(i Issue, o CSVOptions) string { return days(i.Created, Time{o.Now}) }},
+	{"timeToResolutionDays", "resolutiondate", func(i Issue, o CSVOptions) string { return days(i.Created, i.ResolutionDate) }},
+	{"daysSinceUpdate", "updated", func(i Issue, o CSVOptions) string { return days(i.Updated, Time{o.Now}) }},
+}
+
+// timeTrackingString returns the time tracking value or an empty string if time tracking is not available
+func timeTrackingString(t *TimeTracking, value func(TimeTracking) int) string {
+	if t == nil {
+		return ""
+	}
+	return strconv.Itoa(value(*t))
+}
+
+// selectCSVColumns returns the columns of the selected field IDs. The key column
+// is always included. All columns are returned if no fields are selected or
+// a wildcard like "*all" or "*navigable" is selected. Excluded fields like
+// "-description" drop their columns, a selection of exclusions only keeps
+// all other columns like Jira does.
+func selectCSVColumns(fields []string) []csvColumn {
+	selected := map[string]bool{}
+	excluded := map[string]bool{}
+	wildcard := false
+	for _, f := range fields {
+		f = strings.ToLower(f)
+		switch {
+		case strings.HasPrefix(f, "*"):
+			wildcard = true
+		case strings.HasPrefix(f, "-"):
+			excluded[f[1:]] = true
+		default:
+			selected[f] = true
+		}
+	}
+	all := wildcard || len(selected) == 0
+
+	columns := []csvColumn{}
+	for _, c := range csvColumns {
+		if c.field == "key" || (all || selected[c.field]) && !excluded[c.field] {
+			columns = append(columns, c)
+		}
+	}
+	return columns
+}
+
+// WriteCSV writes the Issues to a CSV file. If field IDs are given, only
+// the columns derived from these fields are written.
+func (i *Issues) WriteCSV(filename string, fields ...string) error {
+	return i.WriteCSVWithOptions(filename, CSVOptions{Fields: fields})
+}
+
+// WriteCSVWithOptions writes the Issues to a CSV file using the options
+func (i *Issues) WriteCSVWithOptions(filename string, o CSVOptions) error {
+	if o.Now.IsZero() {
+		o.Now = time.Now()
+	}
+
 	// Open the output CSV file for writing.
 	file, err := os.Create(filename)
 	if err != nil {
@@ -21,41 +146,28 @@ func (i *Issues) WriteCSV(filename string) error {
 
 	// Create a new CSV writer.
 	writer := csv.NewWriter(file)
+	columns := selectCSVColumns(o.Fields)
+
+	customColumns := i.customFieldNames()
 
 	// Write the header
-	header := []string{
-		"key",
-		"reporter",
-		"assignee",
-		"creator",
-		"title",
-		"components",
-		"status",
-		"issuetype",
-		"resolutiondate",
-		"updated",
-		"created",
-		"statusCategoryChangeDate",
+	header := make([]string, len(columns), len(columns)+len(customColumns))
+	for n, c := range columns {
+		header[n] = c.name
 	}
+	header = append(header, customColumns...)
 	if err := writer.Write(header); err != nil {
 		return fmt.Errorf("error writing header: %v", err)
 	}
 
 	// Write the rows
 	for _, issue := range *i {
-		row := []string{
-			issue.Key,
-			issue.Reporter.DisplayName,
-			issue.Assignee.DisplayName,
-			issue.Creator.DisplayName,
-			issue.Title,
-			strings.Join(issue.Components, "|"),
-			issue.Status,
-			issue.IssueType,
-			issue.ResolutionDate,
-			issue.Updated,
-			issue.Created,
-			issue.StatusCategoryChangeDate,
+		row := make([]string, len(columns), len(columns)+len(customColumns))
+		for n, c := range columns {
+			row[n] = c.value(issue, o)
+		}
+		for _, name := range customColumns {
+			row = append(row, CustomFieldString(issue.CustomFields[name]))
 		}
 		if err := writer.Write(row); err != nil {
 			return fmt.Errorf("error writing row: %v", err)
@@ -68,253 +180,248 @@ func (i *Issues) WriteCSV(filename string) error {
 	return nil
 }
 
-// Issue
+// In converts the timestamps of the issues to the location
+func (i Issues) In(loc *time.Location) {
+	for n := range i {
+		i[n].Created = i[n].Created.In(loc)
+		i[n].Updated = i[n].Updated.In(loc)
+		i[n].ResolutionDate = i[n].ResolutionDate.In(loc)
+		i[n].StatusCategoryChangeDate = i[n].StatusCategoryChangeDate.In(loc)
+	}
+}
+
+// Issue is the exported model of an issue. Its fields and their JSON names
+// are stable, so other Go code can depend on them.
 type Issue struct {
-	Assignee                 JiraIssueUser `json:"assignee"`
-	Components               []string      `json:"components"`
-	Created                  string        `json:"created"`
-	Creator                  JiraIssueUser `json:"creator"`
-	Description              string        `json:"description"`
-	ID                       string        `json:"id"`
-	IssueType                string        `json:"issuetype"`
-	Key                      string        `json:"key"`
+	AffectedVersions []string      `json:"affectedVersions,omitempty"`
+	Assignee         JiraIssueUser `json:"assignee"`
+	Components       []string      `json:"components"`
+	Created          Time          `json:"created"`
+	Creator          JiraIssueUser `json:"creator"`
+	Description      string        `json:"description"`
+	DueDate          string        `json:"duedate,omitempty"`
+	Environment      string        `json:"environment,omitempty"`
+	FixVersions      []string      `json:"fixVersions,omitempty"`
+	ID               string        `json:"id"`
+	IssueType        string        `json:"issuetype"`
+	Key              string        `json:"key"`
+	Labels           []string      `json:"labels,omitempty"`
+	Parent           string        `json:"parent,omitempty"`
+	// Epic is the key of the epic, from the parent or the legacy Epic Link field
+	Epic                     string        `json:"epic,omitempty"`
+	Priority                 string        `json:"priority,omitempty"`
+	ProjectKey               string        `json:"projectKey"`
+	ProjectName              string        `json:"projectName"`
+	Subtasks                 []IssueRef    `json:"subtasks,omitempty"`
+	Links                    []IssueLink   `json:"links,omitempty"`
 	Reporter                 JiraIssueUser `json:"reporter"`
-	ResolutionDate           string        `json:"resolutiondate"`
+	Resolution               string        `json:"resolution,omitempty"`
+	ResolutionDate           Time          `json:"resolutiondate"`
+	SecurityLevel            string        `json:"securityLevel,omitempty"`
 	Self                     string        `json:"self"`
 	Summary                  string        `json:"summary"`
 	Status                   string        `json:"status"`
-	StatusCategoryChangeDate string        `json:"statuscategorychangedate"`
-	Title                    string        `json:"title"`
-	Updated                  string        `json:"updated"`
+	StatusCategory           string        `json:"statusCategory"`
+	StatusCategoryChangeDate Time          `json:"statuscategorychangedate"`
+	// TimeTracking is nil if time tracking is disabled or the fields were not selected
+	TimeTracking *TimeTracking `json:"timetracking,omitempty"`
+	Title        string        `json:"title"`
+	Updated      Time          `json:"updated"`
+	Votes        int           `json:"votes"`
+	WatchCount   int           `json:"watchCount"`
+	// CustomFields contains the values of the custom fields keyed by field ID,
+	// or by field name after NameCustomFields was called
+	CustomFields map[string]any `json:"customFields,omitempty"`
 }
 
-func IssueFromInterface(i any) (issue Issue, err error) {
+// TimeTracking contains the estimates and the logged time of an issue in seconds
+type TimeTracking struct {
+	OriginalEstimateSeconds  int `json:"originalEstimateSeconds"`
+	RemainingEstimateSeconds int `json:"remainingEstimateSeconds"`
+	TimeSpentSeconds         int `json:"timeSpentSeconds"`
+}
 
-	// Convert the interface to a map
-	issueMap, ok := i.(map[string]any)
-	if !ok {
-		return issue, fmt.Errorf("error converting raw issue to map")
+// NewIssue converts an issue of the search response into an Issue
+func NewIssue(s SearchIssue) (issue Issue, err error) {
+	f := s.Fields
+
+	issue.ID = s.ID
+	issue.Key = s.Key
+	issue.Self = s.Self
+	issue.Title = f.Summary
+	issue.Summary = f.Summary
+	issue.Description = renderRichText(f.Description)
+	// Set the timestamps, an unparseable timestamp fails the conversion
+	timestamps := []struct {
+		field string
+		value string
+		dest  *Time
+	}{
+		{"created", f.Created, &issue.Created},
+		{"updated", f.Updated, &issue.Updated},
+		{"resolutiondate", f.ResolutionDate, &issue.ResolutionDate},
+		{"statuscategorychangedate", f.StatusCategoryChangeDate, &issue.StatusCategoryChangeDate},
+	}
+	for _, t := range timestamps {
+		if *t.dest, err = parseIssueTime(t.field, t.value); err != nil {
+			return issue, fmt.Errorf("error converting issue %s: %v", s.Key, err)
+		}
 	}
 
-	// Extract the "fields" object from the issueMap
-	fieldsMap, ok := issueMap["fields"].(map[string]any)
-	if !ok {
-		return issue, fmt.Errorf("error converting fields to map")
+	if f.Assignee != nil {
+		issue.Assignee = *f.Assignee
 	}
-
-	// Set the assignee
-	if assignee, ok := fieldsMap["assignee"].(map[string]any); ok {
-		issue.Assignee.FromInterface(assignee)
+	if f.Reporter != nil {
+		issue.Reporter = *f.Reporter
 	}
-
-	// Set the Title field
-	if title, ok := fieldsMap["summary"].(string); ok {
-		issue.Title = title
+	if f.Creator != nil {
+		issue.Creator = *f.Creator
 	}
-
-	// Set the Description field
-	if description, ok := fieldsMap["description"].(map[string]any); ok {
-		issue.Description = extractDescription(description)
+	if f.IssueType != nil {
+		issue.IssueType = f.IssueType.Name
 	}
-
-	// Set the components
-	if components, ok := fieldsMap["components"].([]any); ok {
-		for _, component := range components {
-			if c, ok := component.(map[string]any); ok {
-				if name, ok := c["name"].(string); ok {
-					issue.Components = append(issue.Components, name)
-				}
-			}
-		}
+	if f.Status != nil {
+		issue.Status = f.Status.Name
 	}
-
-	// Set the created date
-	if created, ok := fieldsMap["created"].(string); ok {
-		issue.Created = created
+	for _, c := range f.Components {
+		issue.Components = append(issue.Components, c.Name)
 	}
-
-	// Set the Creator field
-	if creator, ok := fieldsMap["creator"].(map[string]any); ok {
-		issue.Creator.FromInterface(creator)
+	if f.Status != nil {
+		issue.StatusCategory = f.Status.StatusCategory.Name
 	}
-
-	// Set the ID field
-	if id, ok := issueMap["id"].(string); ok {
-		issue.ID = id
+	if f.Priority != nil {
+		issue.Priority = f.Priority.Name
 	}
-
-	// Set the IssueType field
-	if issueType, ok := fieldsMap["issuetype"].(map[string]any); ok {
-		if name, ok := issueType["name"].(string); ok {
-			issue.IssueType = name
-		}
+	if f.Resolution != nil {
+		issue.Resolution = f.Resolution.Name
 	}
-
-	// Set the Key field
-	if key, ok := issueMap["key"].(string); ok {
-		issue.Key = key
+	if f.Project != nil {
+		issue.ProjectKey = f.Project.Key
+		issue.ProjectName = f.Project.Name
 	}
-
-	// Set the Reporter field
-	if reporter, ok := fieldsMap["reporter"].(map[string]any); ok {
-		issue.Reporter.FromInterface(reporter)
+	if f.Security != nil {
+		issue.SecurityLevel = f.Security.Name
 	}
-
-	// Set the ResolutionDate field
-	if resolutionDate, ok := fieldsMap["resolutiondate"].(string); ok {
-		issue.ResolutionDate = resolutionDate
+	if f.Votes != nil {
+		issue.Votes = f.Votes.Votes
 	}
-
-	// Set the Self field
-	if self, ok := issueMap["self"].(string); ok {
-		issue.Self = self
+	if f.Watches != nil {
+		issue.WatchCount = f.Watches.WatchCount
 	}
-
-	// Set the Summary field
-	if summary, ok := fieldsMap["summary"].(string); ok {
-		issue.Summary = summary
+	for _, v := range f.FixVersions {
+		issue.FixVersions = append(issue.FixVersions, v.Name)
 	}
-
-	// Set the Status field
-	if status, ok := fieldsMap["status"].(map[string]any); ok {
-		if name, ok := status["name"].(string); ok {
-			issue.Status = name
-		}
+	for _, v := range f.Versions {
+		issue.AffectedVersions = append(issue.AffectedVersions, v.Name)
 	}
+	issue.Labels = f.Labels
+	issue.DueDate = f.DueDate
+	issue.Environment = renderRichText(f.Environment)
+	issue.TimeTracking = f.timeTracking()
 
-	// Set the StatusCategoryChangeDate field
-	if statusCategoryChangeDate, ok := fieldsMap["statuscategorychangedate"].(string); ok {
-		issue.StatusCategoryChangeDate = statusCategoryChangeDate
-	}
-
-	// Set the Updated field
-	if updated, ok := fieldsMap["updated"].(string); ok {
-		issue.Updated = updated
-	}
-
-	return issue, nil
-}
-
-func extractDescription(i map[string]any) string {
-	var out string
-
-	// If the type is a doc, then extract the content
-	if i["type"] == "doc" {
-		if content, ok := i["content"].([]any); ok {
-			for _, c := range content {
-				if m, ok := c.(map[string]any); ok {
-					out = out + extractDescription(m)
-				}
-			}
+	// Set the Parent and Epic fields
+	if f.Parent != nil && f.Parent.Key != "" {
+		issue.Parent = f.Parent.Key
+		if f.Parent.IsEpic() {
+			issue.Epic = f.Parent.Key
 		}
 	}
 
-	// If the type is a paragraph, then extract the content
-	if i["type"] == "paragraph" {
-		if content, ok := i["content"].([]any); ok {
-			for _, c := range content {
-				if m, ok := c.(map[string]any); ok {
-					out = out + extractDescription(m)
-				}
-			}
-		}
+	for _, subtask := range f.Subtasks {
+		issue.Subtasks = append(issue.Subtasks, subtask.Ref())
 	}
 
-	// If the type is a table, then extract the content from the cells
-	if i["type"] == "table" {
-		if content, ok := i["content"].([]any); ok {
-			for _, c := range content {
-				if row, ok := c.(map[string]any); ok {
-					out = out + extractDescription(row)
-				}
-			}
+	for _, l := range f.IssueLinks {
+		if link, ok := l.Link(); ok {
+			issue.Links = append(issue.Links, link)
 		}
 	}
 
-	// If the type is a tableRow, then extract the content from the cells
-	if i["type"] == "tableRow" {
-		if content, ok := i["content"].([]any); ok {
-			for _, c := range content {
-				if cell, ok := c.(map[string]any); ok {
-					out = out + extractDescription(cell)
-				}
-			}
+	// Set the custom fields which have a value
+	for id := range s.RawFields {
+		if !strings.HasPrefix(id, "customfield_") {
+			continue
 		}
-	}
 
-	// If the type is a tableCell, then extract the content from the paragraphs
-	if i["type"] == "tableCell" {
-		if content, ok := i["content"].([]any); ok {
-			for _, c := range content {
-				if paragraph, ok := c.(map[string]any); ok {
-					out = out + extractDescription(paragraph)
-				}
-			}
+		var value any
+		ok, err := s.Field(id, &value)
+		if err != nil {
+			return issue, err
 		}
-	}
-
-	if i["type"] == "inlineCard" {
-		if attrs, ok := i["attrs"].(map[string]any); ok {
-			if url, ok := attrs["url"].(string); ok {
-				out = out + "<" + url + ">"
-			}
+		if !ok {
+			continue
 		}
-	}
-
-	if i["type"] == "bulletList" {
-		if content, ok := i["content"].([]any); ok {
-			for _, c := range content {
-				if m, ok := c.(map[string]any); ok {
-					out = out + extractDescription(m)
-				}
-			}
+		if issue.CustomFields == nil {
+			issue.CustomFields = map[string]any{}
 		}
+		issue.CustomFields[id] = value
 	}
 
-	if i["type"] == "listItem" {
-		if content, ok := i["content"].([]any); ok {
-			for _, c := range content {
-				if m, ok := c.(map[string]any); ok {
-					out = out + " * " + extractDescription(m) + "\n"
-				}
-			}
-		}
-	}
+	return issue, nil
+}
 
-	// If the type is a text, then extract the text
-	if i["type"] == "text" {
-		if text, ok := i["text"].(string); ok {
-			if strings.Contains("Termination", text) {
-				fmt.Println("Termination")
-			}
+// IssueFromInterface converts a raw issue as decoded into interface{} into an Issue.
+// It is kept for compatibility, use NewIssue for a SearchIssue.
+func IssueFromInterface(i any) (issue Issue, err error) {
+	var s SearchIssue
+	if err := decodeRaw(i, &s); err != nil {
+		return issue, fmt.Errorf("error converting raw issue: %v", err)
+	}
+	return NewIssue(s)
+}
 
-			out = strings.TrimSpace(text)
+// renderRichText converts a rich text value to text. Jira Cloud returns rich
+// text as Atlassian Document Format, which is rendered as Markdown, Jira
+// Server as a wiki markup string.
+func renderRichText(v any) string {
+	switch text := v.(type) {
+	case map[string]any:
+		md, err := adf.ToMarkdown(text)
+		if err != nil {
+			logger.Logger.Warn("Unable to render rich text", "error", err)
 		}
+		return md
+	case string:
+		return text
 	}
-
-	return out
+	return ""
 }
 
 type JiraIssueUser struct {
-	Self         string `json:"self"`
+	Self string `json:"self"`
+	// AccountID identifies the user on Jira Cloud
+	AccountID string `json:"accountId,omitempty"`
+	// Name and Key identify the user on Jira Server
+	Name         string `json:"name,omitempty"`
+	Key          string `json:"key,omitempty"`
 	DisplayName  string `json:"displayName"`
 	Active       bool   `json:"active"`
 	EmailAddress string `json:"emailAddress,omitempty"`
 }
 
+// ID returns the account ID on Jira Cloud or the key on Jira Server
+func (j JiraIssueUser) ID() string {
+	if j.AccountID != "" {
+		return j.AccountID
+	}
+	if j.Key != "" {
+		return j.Key
+	}
+	return j.Name
+}
+
+// FromInterface sets the user from a raw user as decoded into interface{}.
+// Missing attributes are left empty, e.g. for app users or anonymized accounts.
 func (j *JiraIssueUser) FromInterface(i any) error {
 	m, ok := i.(map[string]any)
 	if !ok {
 		return fmt.Errorf("error converting to map")
 	}
 
-	j.Self = m["self"].(string)
-	j.Active = m["active"].(bool)
-	if m["emailAddress"] != nil {
-		j.EmailAddress = m["emailAddress"].(string)
+	*j = JiraIssueUser{}
+	if err := decodeRaw(m, j); err != nil {
+		return fmt.Errorf("error converting user: %v", err)
 	}
-	j.DisplayName = m["displayName"].(string)
-	j.DisplayName = strings.Replace(j.DisplayName, ",", "", -1)
 	j.DisplayName = strings.TrimSpace(j.DisplayName)
 
 	return nil
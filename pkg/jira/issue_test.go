@@ -0,0 +1,324 @@
+package jira
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNameCustomFields tests that custom fields are keyed by their names after conversion
+func TestNameCustomFields(t *testing.T) {
+	issue, err := IssueFromInterface(map[string]any{
+		"key": "TEST-1",
+		"fields": map[string]any{
+			"customfield_10016": 5.0,
+			"customfield_10020": []any{map[string]any{"id": 1.0, "name": "Sprint 1"}},
+			"customfield_10030": nil,
+		},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, issue.CustomFields, 2)
+
+	issues := Issues{issue}
+	issues.NameCustomFields(NewFieldMap([]Field{
+		{ID: "customfield_10016", Name: "Story Points"},
+		{ID: "customfield_10020", Name: "Sprint"},
+	}))
+
+	assert.Equal(t, "5", CustomFieldString(issues[0].CustomFields["Story Points"]))
+	assert.Equal(t, "Sprint 1", CustomFieldString(issues[0].CustomFields["Sprint"]))
+	assert.Equal(t, []string{"Sprint", "Story Points"}, issues.customFieldNames())
+}
+
+// TestTimesheet tests the pivot of the worklogs by user, week and epic
+func TestTimesheet(t *testing.T) {
+	issues := Issues{
+		{Key: "TEST-1", Epic: "TEST-100", Components: []string{"API", "UI"}},
+		{Key: "TEST-2"},
+	}
+	worklogs := Worklogs{
+		{IssueKey: "TEST-1", Author: JiraIssueUser{AccountID: "a1", DisplayName: "Alice"}, Started: "2024-01-02T10:00:00.000+0100", TimeSpentSeconds: 3600},
+		{IssueKey: "TEST-1", Author: JiraIssueUser{AccountID: "a1", DisplayName: "Alice"}, Started: "2024-01-03T10:00:00.000+0100", TimeSpentSeconds: 1800},
+		{IssueKey: "TEST-1", Author: JiraIssueUser{AccountID: "a2", DisplayName: "Alice"}, Started: "2024-01-03T10:00:00.000+0100", TimeSpentSeconds: 900},
+		{IssueKey: "TEST-2", Author: JiraIssueUser{AccountID: "b1", DisplayName: "Bob"}, Started: "2024-01-08T10:00:00.000+0100", TimeSpentSeconds: 7200},
+		{IssueKey: "TEST-2", Author: JiraIssueUser{AccountID: "b1", DisplayName: "Bob"}, Started: "soon", TimeSpentSeconds: 3600},
+	}
+
+	timesheet, err := worklogs.Timesheet(issues, TimesheetByEpic)
+	assert.NoError(t, err)
+	assert.Equal(t, Timesheet{
+		{UserID: "a1", User: "Alice", Week: "2024-W01", Group: "TEST-100", Hours: 1.5},
+		{UserID: "a2", User: "Alice", Week: "2024-W01", Group: "TEST-100", Hours: 0.25},
+		{UserID: "b1", User: "Bob", Week: "2024-W02", Group: "(none)", Hours: 2},
+	}, timesheet)
+
+	timesheet, err = worklogs.Timesheet(issues, TimesheetByComponent)
+	assert.NoError(t, err)
+	assert.Equal(t, 0.75, timesheet[0].Hours)
+	assert.Equal(t, "API", timesheet[0].Group)
+}
+
+func TestIssueSprintMemberships(t *testing.T) {
+	issues := searchIssues(t,
+		map[string]any{"key": "TEST-1", "fields": map[string]any{
+			"customfield_10020": []any{
+				map[string]any{"id": float64(1), "name": "Sprint 1", "state": "closed", "boardId": float64(3)},
+				map[string]any{"id": float64(2), "name": "Sprint 2", "state": "active", "boardId": float64(3)},
+			},
+		}},
+		map[string]any{"key": "TEST-2", "fields": map[string]any{
+			"customfield_10020": []any{
+				// Jira 7 prints the goal right after the name
+				"com.atlassian.greenhopper.service.sprint.Sprint@1a2b[id=2,rapidViewId=3,state=ACTIVE,name=Sprint 2,goal=Finish, then ship,startDate=<null>,endDate=<null>,completeDate=<null>,sequence=2]",
+				"com.atlassian.greenhopper.service.sprint.Sprint@3c4d[id=4,rapidViewId=3,state=FUTURE,name=Team A, Sprint 5,startDate=<null>,endDate=<null>,completeDate=<null>,activatedDate=<null>,sequence=4,goal=Ship x=y, then relax]",
+			},
+		}},
+	)
+
+	memberships, sprints, err := IssueSprintMemberships(issues, []string{"customfield_10020"})
+	assert.NoError(t, err)
+	assert.Len(t, memberships, 4)
+	assert.Equal(t, IssueSprint{IssueKey: "TEST-2", SprintID: 2, SprintName: "Sprint 2", SprintState: "active"}, memberships[2])
+	assert.Equal(t, IssueSprint{IssueKey: "TEST-2", SprintID: 4, SprintName: "Team A, Sprint 5", SprintState: "future"}, memberships[3])
+	assert.Len(t, sprints, 3)
+	assert.Equal(t, "Ship x=y, then relax", sprints[2].Goal)
+	assert.Equal(t, 3, sprints[0].OriginBoardID)
+
+	sprint, ok := parseLegacySprint("com.atlassian.greenhopper.service.sprint.Sprint@1a2b[id=2,rapidViewId=3,state=ACTIVE,name=Sprint 2,goal=Finish, then ship,startDate=<null>,endDate=<null>,completeDate=<null>,sequence=2]")
+	assert.True(t, ok)
+	assert.Equal(t, "Sprint 2", sprint.Name)
+	assert.Equal(t, "Finish, then ship", sprint.Goal)
+}
+
+func TestIssueLinksAndEpics(t *testing.T) {
+	raw := []any{
+		map[string]any{"key": "TEST-1", "fields": map[string]any{
+			"parent": map[string]any{"key": "TEST-100", "fields": map[string]any{"issuetype": map[string]any{"name": "Feature", "hierarchyLevel": float64(1)}}},
+			"subtasks": []any{
+				map[string]any{"key": "TEST-2", "fields": map[string]any{"status": map[string]any{"name": "Done"}}},
+			},
+			"issuelinks": []any{
+				map[string]any{"id": "1", "type": map[string]any{"name": "Blocks", "inward": "is blocked by", "outward": "blocks"},
+					"inwardIssue": map[string]any{"key": "TEST-3", "fields": map[string]any{"status": map[string]any{"name": "Open"}}}},
+			},
+		}},
+		map[string]any{"key": "TEST-2", "fields": map[string]any{
+			"parent": map[string]any{"key": "TEST-1", "fields": map[string]any{"issuetype": map[string]any{"name": "Story", "hierarchyLevel": float64(0)}}},
+		}},
+		map[string]any{"key": "TEST-4", "fields": map[string]any{
+			"customfield_10014": "TEST-200",
+		}},
+		map[string]any{"key": "TEST-5", "fields": map[string]any{
+			"parent": map[string]any{"key": "TEST-50", "fields": map[string]any{"issuetype": map[string]any{"name": "Story", "hierarchyLevel": float64(0)}}},
+		}},
+	}
+
+	issues := Issues{}
+	for _, r := range raw {
+		issue, err := IssueFromInterface(r)
+		assert.NoError(t, err)
+		issues = append(issues, issue)
+	}
+	missingParents := issues.ResolveEpics([]string{"customfield_10014"})
+
+	assert.Equal(t, []string{"TEST-50"}, missingParents)
+	assert.Equal(t, "", issues[3].Epic)
+	assert.Equal(t, "TEST-100", issues[0].Epic)
+	assert.Equal(t, "TEST-100", issues[1].Epic)
+	assert.Equal(t, "TEST-1", issues[1].Parent)
+	assert.Equal(t, "TEST-200", issues[2].Epic)
+	assert.Equal(t, IssueLink{ID: "1", Type: "Blocks", Direction: LinkInward, Description: "is blocked by", Key: "TEST-3", Status: "Open"}, issues[0].Links[0])
+
+	edges := issues.Links()
+	assert.Len(t, edges, 5)
+	assert.Equal(t, LinkTypeSubtask, edges[1].Type)
+	assert.Equal(t, "TEST-2", edges[1].IssueLink.Key)
+}
+
+// TestSearchIssueKeepsRawFields tests the typed decoding and that unmapped fields are kept
+func TestSearchIssueKeepsRawFields(t *testing.T) {
+	data := `{"id":"1","key":"TEST-1","fields":{"summary":"Hello","status":{"name":"Done","statusCategory":{"key":"done"}},
+		"assignee":{"accountId":"42","displayName":"Doe, Jane"},"customfield_10016":3,"customfield_10030":null}}`
+
+	var s SearchIssue
+	assert.NoError(t, json.Unmarshal([]byte(data), &s))
+	assert.Equal(t, "Done", s.Fields.Status.Name)
+	assert.Equal(t, "done", s.Fields.Status.StatusCategory.Key)
+
+	var points float64
+	ok, err := s.Field("customfield_10016", &points)
+	assert.True(t, ok)
+	assert.NoError(t, err)
+	assert.Equal(t, 3.0, points)
+	ok, _ = s.Field("customfield_10030", &points)
+	assert.False(t, ok)
+
+	issue, err := NewIssue(s)
+	assert.NoError(t, err)
+	assert.Equal(t, "Doe, Jane", issue.Assignee.DisplayName)
+	assert.Equal(t, "42", issue.Assignee.AccountID)
+	assert.Equal(t, map[string]any{"customfield_10016": 3.0}, issue.CustomFields)
+
+	// The issue is marshalled as returned by Jira
+	out, err := json.Marshal(s)
+	assert.NoError(t, err)
+	assert.Contains(t, string(out), `"customfield_10016":3`)
+}
+
+// TestConvertIssuesQuarantinesMalformedIssues tests that lenient mode rejects single issues
+func TestConvertIssuesQuarantinesMalformedIssues(t *testing.T) {
+	data := `{"issues":[
+		{"key":"TEST-1","fields":{"summary":"Fine","assignee":{"accountId":"1"}}},
+		{"key":"TEST-2","fields":{"summary":"Broken","assignee":{"displayName":"App","active":"yes"}}},
+		{"key":"TEST-3","fields":"none"}]}`
+
+	var results JiraSearchResults
+	assert.NoError(t, json.Unmarshal([]byte(data), &results))
+
+	_, err := results.IssuesToJiraIssues()
+	assert.Error(t, err)
+
+	issues, accepted, rejects, err := results.ConvertIssues(DecodeLenient)
+	assert.NoError(t, err)
+	assert.Len(t, issues, 1)
+	assert.Len(t, accepted, 1)
+	assert.Equal(t, "TEST-1", accepted[0].Key)
+	assert.Len(t, rejects, 2)
+	assert.Equal(t, "TEST-2", rejects[0].Key)
+	assert.Equal(t, "TEST-3", rejects[1].Key)
+	assert.JSONEq(t, `{"key":"TEST-3","fields":"none"}`, string(rejects[1].Issue))
+
+	var user JiraIssueUser
+	assert.NoError(t, user.FromInterface(map[string]any{"accountId": "2"}))
+	assert.Equal(t, "2", user.AccountID)
+}
+
+// TestNewIssueStandardFields tests the mapping of the extended standard fields
+func TestNewIssueStandardFields(t *testing.T) {
+	issue, err := IssueFromInterface(map[string]any{
+		"key": "TEST-1",
+		"fields": map[string]any{
+			"project":     map[string]any{"id": "10000", "key": "TEST", "name": "Test project"},
+			"status":      map[string]any{"name": "In Review", "statusCategory": map[string]any{"key": "indeterminate", "name": "In Progress"}},
+			"priority":    map[string]any{"name": "High"},
+			"resolution":  nil,
+			"labels":      []any{"backend", "urgent"},
+			"fixVersions": []any{map[string]any{"name": "1.0"}, map[string]any{"name": "1.1"}},
+			"versions":    []any{map[string]any{"name": "0.9"}},
+			"duedate":     "2024-01-31",
+			"environment": "Production",
+			"timespent":   3600.0,
+			"votes":       map[string]any{"votes": 2.0},
+			"watches":     map[string]any{"watchCount": 5.0},
+			"security":    map[string]any{"name": "Internal"},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "TEST", issue.ProjectKey)
+	assert.Equal(t, "In Progress", issue.StatusCategory)
+	assert.Equal(t, "High", issue.Priority)
+	assert.Equal(t, "", issue.Resolution)
+	assert.Equal(t, []string{"1.0", "1.1"}, issue.FixVersions)
+	assert.Equal(t, []string{"0.9"}, issue.AffectedVersions)
+	assert.Equal(t, &TimeTracking{TimeSpentSeconds: 3600}, issue.TimeTracking)
+	assert.Equal(t, 5, issue.WatchCount)
+	assert.Equal(t, "Internal", issue.SecurityLevel)
+
+	columns := []string{}
+	for _, c := range selectCSVColumns([]string{"fixVersions", "timespent"}) {
+		columns = append(columns, c.name)
+	}
+	assert.Equal(t, []string{"key", "fixVersions", "timeSpentSeconds"}, columns)
+}
+
+// TestSelectCSVColumnsWithExclusions tests that excluded fields drop their columns
+func TestSelectCSVColumnsWithExclusions(t *testing.T) {
+	names := func(fields ...string) []string {
+		columns := []string{}
+		for _, c := range selectCSVColumns(fields) {
+			columns = append(columns, c.name)
+		}
+		return columns
+	}
+
+	assert.Len(t, names("-environment"), len(csvColumns)-1)
+	assert.NotContains(t, names("-environment"), "environment")
+	assert.NotContains(t, names("*all", "-summary"), "title")
+	assert.Equal(t, []string{"key", "issuetype"}, names("issuetype", "summary", "-summary"))
+}
+
+// TestIssueTimestamps tests the parsing, the time zone conversion and the derived durations
+func TestIssueTimestamps(t *testing.T) {
+	issue, err := IssueFromInterface(map[string]any{
+		"key": "TEST-1",
+		"fields": map[string]any{
+			"created":        "2024-01-01T10:00:00.000+0100",
+			"updated":        "2024-01-05T10:00:00.000+0100",
+			"resolutiondate": "2024-01-03T22:00:00.000+0100",
+		},
+	})
+	assert.NoError(t, err)
+
+	issues := Issues{issue}
+	issues.In(time.UTC)
+	assert.Equal(t, "2024-01-01 09:00:00", issues[0].Created.Format(time.DateTime))
+
+	data, err := json.Marshal(issues[0])
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"created":"2024-01-01T09:00:00Z"`)
+	assert.Contains(t, string(data), `"statuscategorychangedate":null`)
+
+	filename := t.TempDir() + "/issues.csv"
+	now := time.Date(2024, 1, 11, 9, 0, 0, 0, time.UTC)
+	err = issues.WriteCSVWithOptions(filename, CSVOptions{Fields: []string{"created", "resolutiondate"}, DateFormat: DateFormatDate, Now: now})
+	assert.NoError(t, err)
+
+	csv, err := os.ReadFile(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, "key,resolutiondate,created,ageDays,timeToResolutionDays\nTEST-1,2024-01-03,2024-01-01,10.0,2.5\n", string(csv))
+
+	_, err = IssueFromInterface(map[string]any{"key": "TEST-2", "fields": map[string]any{"created": "yesterday"}})
+	assert.Error(t, err)
+}
+
+// TestRichTextIsRenderedAsMarkdown tests that descriptions and rich text custom fields are converted to Markdown
+func TestRichTextIsRenderedAsMarkdown(t *testing.T) {
+	doc := map[string]any{"type": "doc", "content": []any{
+		map[string]any{"type": "heading", "attrs": map[string]any{"level": 1.0}, "content": []any{map[string]any{"type": "text", "text": "Summary"}}},
+		map[string]any{"type": "paragraph", "content": []any{
+			map[string]any{"type": "text", "text": "Hello "},
+			map[string]any{"type": "text", "text": "world", "marks": []any{map[string]any{"type": "strong"}}},
+		}},
+	}}
+
+	issue, err := IssueFromInterface(map[string]any{"key": "TEST-1", "fields": map[string]any{"description": doc}})
+	assert.NoError(t, err)
+	assert.Equal(t, "# Summary\n\nHello **world**", issue.Description)
+	assert.Equal(t, "# Summary\n\nHello **world**", CustomFieldString(doc))
+}
+
+// TestReferencedUsers tests that users are collected from the issues, user pickers and the exported entities
+func TestReferencedUsers(t *testing.T) {
+	issues := Issues{{
+		Key:      "TEST-1",
+		Assignee: JiraIssueUser{AccountID: "1", DisplayName: "Bob"},
+		Reporter: JiraIssueUser{AccountID: "1", DisplayName: "Bob"},
+		CustomFields: map[string]any{
+			"Approvers": []any{map[string]any{"accountId": "2", "displayName": "Alice"}},
+			"Reviewer":  map[string]any{"self": "https://jira/rest/api/2/user?username=carol", "name": "carol", "key": "JIRAUSER1", "displayName": "Carol"},
+			"Team":      map[string]any{"value": "Core", "id": "10"},
+		},
+	}}
+	comments := Comments{{Author: JiraIssueUser{AccountID: "3", DisplayName: "Dave"}, UpdateAuthor: JiraIssueUser{AccountID: "1"}}}
+	worklogs := Worklogs{{Author: JiraIssueUser{AccountID: "4", DisplayName: "Eve"}}}
+
+	users := UniqueUsers(issues.ReferencedUsers(), comments.Users(), worklogs.Users())
+	names := []string{}
+	for _, u := range users {
+		names = append(names, u.DisplayName)
+	}
+	assert.Equal(t, []string{"Alice", "Bob", "Carol", "Dave", "Eve"}, names)
+}
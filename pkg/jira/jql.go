@@ -0,0 +1,208 @@
+package jira
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrValidationUnsupported is returned by ValidateJQL for Jira Server, which has no JQL parse API
+var ErrValidationUnsupported = errors.New("JQL validation is not supported by Jira Server")
+
+// JQLError is an error in a JQL query. Line and Column are 0 if Jira gives no position.
+type JQLError struct {
+	Message string `json:"message"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+}
+
+// String returns the position and the message of the error
+func (e JQLError) String() string {
+	if e.Line == 0 {
+		return e.Message
+	}
+	return fmt.Sprintf("line %d, character %d: %s", e.Line, e.Column, e.Message)
+}
+
+// JQLValidation is the result of the validation of a JQL query
+type JQLValidation struct {
+	Query    string     `json:"query"`
+	Errors   []JQLError `json:"errors,omitempty"`
+	Warnings []string   `json:"warnings,omitempty"`
+	// Structure is the abstract syntax tree of a valid query
+	Structure json.RawMessage `json:"structure,omitempty"`
+}
+
+// Valid returns true if the query has no errors
+func (v JQLValidation) Valid() bool {
+	return len(v.Errors) == 0
+}
+
+// Err returns an APIError unwrapping to ErrInvalidJQL if the query has errors
+func (v JQLValidation) Err() error {
+	if v.Valid() {
+		return nil
+	}
+
+	apiErr := &APIError{WarningMessages: v.Warnings, kind: ErrInvalidJQL}
+	for _, e := range v.Errors {
+		apiErr.ErrorMessages = append(apiErr.ErrorMessages, e.String())
+	}
+	return apiErr
+}
+
+// jqlPositionPattern matches the position in the error messages of the JQL parser,
+// e.g. "Error in the JQL Query: Expecting ')' before the end of the query. (line 1, character 12)"
+var jqlPositionPattern = regexp.MustCompile(`\(line (\d+), character (\d+)\)`)
+
+// parseJQLError extracts the position from a JQL error message
+func parseJQLError(message string) JQLError {
+	e := JQLError{Message: message}
+	if m := jqlPositionPattern.FindStringSubmatch(message); m != nil {
+		e.Line, _ = strconv.Atoi(m[1])
+		e.Column, _ = strconv.Atoi(m[2])
+		e.Message = strings.TrimSpace(strings.Replace(message, m[0], "", 1))
+	}
+	return e
+}
+
+// ValidateJQL validates the query with the strict JQL parser of Jira. An
+// invalid query is no error, see JQLValidation.Valid. The validation
+// bypasses the cache.
+func (j JiraAPI) ValidateJQL(ctx context.Context, jql string) (JQLValidation, error) {
+	v := JQLValidation{Query: jql}
+	if j.Flavor == FlavorServer {
+		return v, ErrValidationUnsupported
+	}
+
+	body := map[string][]string{"queries": {jql}}
+	req, err := makePostRequest(ctx, j.apiURL("/jql/parse?validation=strict"), j.secrets, body)
+	if err != nil {
+		return v, err
+	}
+
+	resp, err := j.retry(ctx, req, j.sendUncachedRequest)
+	if err != nil {
+		return v, fmt.Errorf("error validating JQL: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Queries []struct {
+			Structure json.RawMessage `json:"structure"`
+			Errors    []string        `json:"errors"`
+			Warnings  []string        `json:"warnings"`
+		} `json:"queries"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return v, HandleJSONDecodeError(err, resp)
+	}
+	if len(result.Queries) == 0 {
+		return v, fmt.Errorf("error validating JQL: no result returned")
+	}
+
+	q := result.Queries[0]
+	v.Structure = q.Structure
+	v.Warnings = q.Warnings
+	for _, e := range q.Errors {
+		v.Errors = append(v.Errors, parseJQLError(e))
+	}
+	return v, nil
+}
+
+// unknownFieldPattern matches the error message of an unknown field,
+// e.g. "Field 'stauts' does not exist or you do not have permission to view it."
+var unknownFieldPattern = regexp.MustCompile(`Field '([^']+)' does not exist`)
+
+// UnknownField returns the unknown field named in the error or an empty string
+func (e JQLError) UnknownField() string {
+	if m := unknownFieldPattern.FindStringSubmatch(e.Message); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// SuggestFields returns up to three field names of the JQL autocomplete data
+// which are similar to the name, the closest first
+func (j JiraAPI) SuggestFields(ctx context.Context, name string) ([]string, error) {
+	var data struct {
+		VisibleFieldNames []struct {
+			Value       string `json:"value"`
+			DisplayName string `json:"displayName"`
+		} `json:"visibleFieldNames"`
+	}
+	if err := j.getJSON(ctx, j.apiURL("/jql/autocompletedata"), &data); err != nil {
+		return nil, fmt.Errorf("error getting JQL autocomplete data: %w", err)
+	}
+
+	candidates := make([]string, 0, len(data.VisibleFieldNames))
+	for _, f := range data.VisibleFieldNames {
+		candidates = append(candidates, f.Value)
+	}
+	return suggest(name, candidates, 3), nil
+}
+
+// suggest returns up to n candidates with a small edit distance to the name, the closest first
+func suggest(name string, candidates []string, n int) []string {
+	type match struct {
+		value    string
+		distance int
+	}
+
+	maxDistance := len(name) / 3
+	if maxDistance < 2 {
+		maxDistance = 2
+	}
+
+	matches := []match{}
+	seen := map[string]bool{}
+	for _, c := range candidates {
+		if seen[c] {
+			continue
+		}
+		seen[c] = true
+
+		d := levenshtein(strings.ToLower(name), strings.ToLower(strings.Trim(c, `"`)))
+		if d <= maxDistance {
+			matches = append(matches, match{c, d})
+		}
+	}
+
+	sort.SliceStable(matches, func(a, b int) bool {
+		return matches[a].distance < matches[b].distance
+	})
+
+	suggestions := []string{}
+	for i := 0; i < len(matches) && i < n; i++ {
+		suggestions = append(suggestions, matches[i].value)
+	}
+	return suggestions
+}
+
+// levenshtein returns the edit distance between a and b
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for i := range prev {
+		prev[i] = i
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i
+		for k := 1; k <= len(rb); k++ {
+			cost := 1
+			if ra[i-1] == rb[k-1] {
+				cost = 0
+			}
+			cur[k] = min(prev[k]+1, cur[k-1]+1, prev[k-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(rb)]
+}
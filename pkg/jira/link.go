@@ -0,0 +1,125 @@
+package jira
+
+import (
+	"jira-export/pkg/output"
+	"sort"
+)
+
+// EpicLinkFieldSchema is the custom schema of the legacy Epic Link field
+const EpicLinkFieldSchema = "com.pyxis.greenhopper.jira:gh-epic-link"
+
+// Link directions as seen from the issue
+const (
+	LinkOutward = "outward"
+	LinkInward  = "inward"
+)
+
+// Link types of the hierarchy edges in the links export
+const (
+	LinkTypeSubtask = "Subtask"
+	LinkTypeParent  = "Parent"
+)
+
+// IssueLink is a link from an issue to another issue
+type IssueLink struct {
+	ID string `json:"id,omitempty"`
+	// Type is the name of the link type, e.g. "Blocks"
+	Type string `json:"type"`
+	// Direction is LinkOutward if the issue is the source of the link
+	Direction string `json:"direction"`
+	// Description is the link type as seen from the issue, e.g. "is blocked by"
+	Description string `json:"description"`
+	Key         string `json:"key"`
+	Status      string `json:"status,omitempty"`
+}
+
+// IssueRef is a reference to another issue, e.g. a subtask or the parent
+type IssueRef struct {
+	Key       string `json:"key"`
+	Status    string `json:"status,omitempty"`
+	IssueType string `json:"issuetype,omitempty"`
+}
+
+// LinkEdge is an edge of the link graph of the exported issues
+type LinkEdge struct {
+	Key string `json:"key"`
+	IssueLink
+}
+
+// LinkEdges contains the edges of the link graph
+type LinkEdges []LinkEdge
+
+// ResolveEpics sets the epic of the issues which have none from the legacy Epic
+// Link fields with the IDs. Issues without an epic inherit the epic of their
+// parent, if the parent is part of the issues, so subtasks are booked on the epic
+// of their story. The epic of a parent outside the issues is unknown, so the keys
+// of these parents are returned sorted. It must be called before NameCustomFields.
+func (i Issues) ResolveEpics(epicLinkFieldIDs []string) (missingParents []string) {
+	for n := range i {
+		for _, id := range epicLinkFieldIDs {
+			if key, ok := i[n].CustomFields[id].(string); ok && i[n].Epic == "" {
+				i[n].Epic = key
+			}
+		}
+	}
+
+	epics := map[string]string{}
+	for _, issue := range i {
+		epics[issue.Key] = issue.Epic
+	}
+	missing := map[string]bool{}
+	for n := range i {
+		if i[n].Epic != "" || i[n].Parent == "" {
+			continue
+		}
+		epic, ok := epics[i[n].Parent]
+		if !ok {
+			missing[i[n].Parent] = true
+		}
+		i[n].Epic = epic
+	}
+
+	for key := range missing {
+		missingParents = append(missingParents, key)
+	}
+	sort.Strings(missingParents)
+	return missingParents
+}
+
+// Links returns the link graph of the issues including the parent and subtask edges
+func (i Issues) Links() LinkEdges {
+	edges := LinkEdges{}
+	for _, issue := range i {
+		if issue.Parent != "" {
+			edges = append(edges, LinkEdge{issue.Key, IssueLink{
+				Type:        LinkTypeParent,
+				Direction:   LinkOutward,
+				Description: "is child of",
+				Key:         issue.Parent,
+			}})
+		}
+		for _, subtask := range issue.Subtasks {
+			edges = append(edges, LinkEdge{issue.Key, IssueLink{
+				Type:        LinkTypeSubtask,
+				Direction:   LinkOutward,
+				Description: "has subtask",
+				Key:         subtask.Key,
+				Status:      subtask.Status,
+			}})
+		}
+		for _, link := range issue.Links {
+			edges = append(edges, LinkEdge{issue.Key, link})
+		}
+	}
+	return edges
+}
+
+// WriteCSV writes one row per edge to a CSV file
+func (l LinkEdges) WriteCSV(filename string) error {
+	header := []string{"key", "type", "direction", "description", "linkedKey", "linkedStatus"}
+	rows := make([][]string, 0, len(l))
+	for _, e := range l {
+		rows = append(rows, []string{e.Key, e.Type, e.Direction, e.Description, e.IssueLink.Key, e.Status})
+	}
+	return output.WriteCSV(filename, header, rows)
+}
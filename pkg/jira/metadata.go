@@ -0,0 +1,279 @@
+package jira
+
+import (
+	"context"
+	"fmt"
+	"jira-export/pkg/output"
+	"net/url"
+	"strconv"
+)
+
+// Project is a Jira project
+type Project struct {
+	ID             string           `json:"id"`
+	Key            string           `json:"key"`
+	Name           string           `json:"name"`
+	ProjectTypeKey string           `json:"projectTypeKey,omitempty"`
+	Lead           *JiraIssueUser   `json:"lead,omitempty"`
+	Category       *ProjectCategory `json:"projectCategory,omitempty"`
+	Archived       bool             `json:"archived,omitempty"`
+}
+
+// ProjectCategory is the category of a project
+type ProjectCategory struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Component is a component of a project
+type Component struct {
+	ID          string         `json:"id"`
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Project     string         `json:"project"`
+	Lead        *JiraIssueUser `json:"lead,omitempty"`
+	// AssigneeType is the default assignee of new issues, e.g. "COMPONENT_LEAD"
+	AssigneeType string `json:"assigneeType,omitempty"`
+}
+
+// Version is a version of a project
+type Version struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	ProjectID   int    `json:"projectId"`
+	Project     string `json:"project"`
+	StartDate   string `json:"startDate,omitempty"`
+	ReleaseDate string `json:"releaseDate,omitempty"`
+	Released    bool   `json:"released"`
+	Archived    bool   `json:"archived"`
+	Overdue     bool   `json:"overdue,omitempty"`
+}
+
+// IssueType is an issue type
+type IssueType struct {
+	ID             string `json:"id"`
+	Name           string `json:"name"`
+	Description    string `json:"description,omitempty"`
+	Subtask        bool   `json:"subtask"`
+	HierarchyLevel int    `json:"hierarchyLevel"`
+}
+
+// Priority is an issue priority
+type Priority struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// Resolution is an issue resolution
+type Resolution struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// Status is a workflow status
+type Status struct {
+	ID             string         `json:"id"`
+	Name           string         `json:"name"`
+	Description    string         `json:"description,omitempty"`
+	StatusCategory StatusCategory `json:"statusCategory"`
+}
+
+// StatusCategory groups the statuses into to do, in progress and done
+type StatusCategory struct {
+	ID        int    `json:"id"`
+	Key       string `json:"key"`
+	Name      string `json:"name"`
+	ColorName string `json:"colorName,omitempty"`
+}
+
+// Metadata contains the reference data of a Jira instance
+type Metadata struct {
+	Projects         []Project        `json:"projects"`
+	Components       []Component      `json:"components"`
+	Versions         []Version        `json:"versions"`
+	IssueTypes       []IssueType      `json:"issueTypes"`
+	Priorities       []Priority       `json:"priorities"`
+	Resolutions      []Resolution     `json:"resolutions"`
+	Statuses         []Status         `json:"statuses"`
+	StatusCategories []StatusCategory `json:"statusCategories"`
+}
+
+// GetProjects returns all projects visible to the user
+func (j JiraAPI) GetProjects(ctx context.Context) ([]Project, error) {
+	query := url.Values{}
+	query.Set("expand", "lead")
+
+	// Jira Server has no paginated project search
+	if j.Flavor == FlavorServer {
+		projects := []Project{}
+		if err := j.getJSON(ctx, j.apiURL("/project?"+query.Encode()), &projects); err != nil {
+			return nil, fmt.Errorf("error getting projects: %w", err)
+		}
+		return projects, nil
+	}
+
+	projects, err := getPagedValues[Project](ctx, j, j.apiURL("/project/search"), query)
+	if err != nil {
+		return nil, fmt.Errorf("error getting projects: %w", err)
+	}
+	return projects, nil
+}
+
+// GetProjectComponents returns the components of a project
+func (j JiraAPI) GetProjectComponents(ctx context.Context, projectKey string) ([]Component, error) {
+	components := []Component{}
+	if err := j.getJSON(ctx, j.apiURL("/project/"+url.PathEscape(projectKey)+"/components"), &components); err != nil {
+		return nil, fmt.Errorf("error getting components of %s: %w", projectKey, err)
+	}
+	return components, nil
+}
+
+// GetProjectVersions returns the versions of a project
+func (j JiraAPI) GetProjectVersions(ctx context.Context, projectKey string) ([]Version, error) {
+	versions := []Version{}
+	if err := j.getJSON(ctx, j.apiURL("/project/"+url.PathEscape(projectKey)+"/versions"), &versions); err != nil {
+		return nil, fmt.Errorf("error getting versions of %s: %w", projectKey, err)
+	}
+	for n := range versions {
+		versions[n].Project = projectKey
+	}
+	return versions, nil
+}
+
+// GetMetadata returns the reference data of the projects with the keys, or of
+// all projects visible to the user if no keys are given
+func (j JiraAPI) GetMetadata(ctx context.Context, projectKeys []string) (Metadata, error) {
+	var m Metadata
+
+	projects, err := j.GetProjects(ctx)
+	if err != nil {
+		return m, err
+	}
+	m.Projects = filterProjects(projects, projectKeys)
+
+	// Fetch the components and versions of the projects in parallel
+	components := make([][]Component, len(m.Projects))
+	versions := make([][]Version, len(m.Projects))
+	err = runPool(ctx, len(m.Projects), j.Concurrency, func(ctx context.Context, i int) error {
+		c, err := j.GetProjectComponents(ctx, m.Projects[i].Key)
+		if err != nil {
+			return err
+		}
+		v, err := j.GetProjectVersions(ctx, m.Projects[i].Key)
+		if err != nil {
+			return err
+		}
+		components[i], versions[i] = c, v
+		return nil
+	})
+	if err != nil {
+		return m, err
+	}
+	for n := range m.Projects {
+		m.Components = append(m.Components, components[n]...)
+		m.Versions = append(m.Versions, versions[n]...)
+	}
+
+	lists := []struct {
+		path string
+		v    any
+	}{
+		{"/issuetype", &m.IssueTypes},
+		{"/priority", &m.Priorities},
+		{"/resolution", &m.Resolutions},
+		{"/status", &m.Statuses},
+		{"/statuscategory", &m.StatusCategories},
+	}
+	for _, l := range lists {
+		if err := j.getJSON(ctx, j.apiURL(l.path), l.v); err != nil {
+			return m, fmt.Errorf("error getting %s: %w", l.path, err)
+		}
+	}
+
+	return m, nil
+}
+
+// filterProjects returns the projects with the keys or all projects if no keys are given
+func filterProjects(projects []Project, keys []string) []Project {
+	if len(keys) == 0 {
+		return projects
+	}
+
+	selected := map[string]bool{}
+	for _, k := range keys {
+		selected[k] = true
+	}
+
+	filtered := []Project{}
+	for _, p := range projects {
+		if selected[p.Key] || selected[p.ID] {
+			filtered = append(filtered, p)
+		}
+	}
+	return filtered
+}
+
+// userName returns the display name of an optional user
+func userName(u *JiraIssueUser) string {
+	if u == nil {
+		return ""
+	}
+	return u.DisplayName
+}
+
+// WriteCSV writes one reference table per entity into the directory
+func (m Metadata) WriteCSV(dir string) error {
+	tables := []struct {
+		name   string
+		header []string
+		rows   [][]string
+	}{
+		{name: "projects", header: []string{"id", "key", "name", "type", "lead", "category", "archived"}},
+		{name: "components", header: []string{"id", "project", "name", "description", "lead", "assigneeType"}},
+		{name: "versions", header: []string{"id", "project", "name", "description", "startDate", "releaseDate", "released", "archived"}},
+		{name: "issuetypes", header: []string{"id", "name", "description", "subtask", "hierarchyLevel"}},
+		{name: "priorities", header: []string{"id", "name", "description"}},
+		{name: "resolutions", header: []string{"id", "name", "description"}},
+		{name: "statuses", header: []string{"id", "name", "description", "statusCategory"}},
+		{name: "status-categories", header: []string{"id", "key", "name", "color"}},
+	}
+
+	for _, p := range m.Projects {
+		category := ""
+		if p.Category != nil {
+			category = p.Category.Name
+		}
+		tables[0].rows = append(tables[0].rows, []string{p.ID, p.Key, p.Name, p.ProjectTypeKey, userName(p.Lead), category, strconv.FormatBool(p.Archived)})
+	}
+	for _, c := range m.Components {
+		tables[1].rows = append(tables[1].rows, []string{c.ID, c.Project, c.Name, c.Description, userName(c.Lead), c.AssigneeType})
+	}
+	for _, v := range m.Versions {
+		tables[2].rows = append(tables[2].rows, []string{v.ID, v.Project, v.Name, v.Description, v.StartDate, v.ReleaseDate, strconv.FormatBool(v.Released), strconv.FormatBool(v.Archived)})
+	}
+	for _, t := range m.IssueTypes {
+		tables[3].rows = append(tables[3].rows, []string{t.ID, t.Name, t.Description, strconv.FormatBool(t.Subtask), strconv.Itoa(t.HierarchyLevel)})
+	}
+	for _, p := range m.Priorities {
+		tables[4].rows = append(tables[4].rows, []string{p.ID, p.Name, p.Description})
+	}
+	for _, r := range m.Resolutions {
+		tables[5].rows = append(tables[5].rows, []string{r.ID, r.Name, r.Description})
+	}
+	for _, s := range m.Statuses {
+		tables[6].rows = append(tables[6].rows, []string{s.ID, s.Name, s.Description, s.StatusCategory.Key})
+	}
+	for _, c := range m.StatusCategories {
+		tables[7].rows = append(tables[7].rows, []string{strconv.Itoa(c.ID), c.Key, c.Name, c.ColorName})
+	}
+
+	for _, t := range tables {
+		if err := output.WriteCSV(fmt.Sprintf("%s/%s.csv", dir, t.name), t.header, t.rows); err != nil {
+			return fmt.Errorf("error writing %s csv: %v", t.name, err)
+		}
+	}
+	return nil
+}
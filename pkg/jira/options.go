@@ -0,0 +1,100 @@
+package jira
+
+import (
+	rutil "jira-export/pkg/request_util"
+	"time"
+)
+
+// Option configures a JiraAPI object
+type Option func(*JiraAPI)
+
+// WithHTTPClient sets the client used to send requests to Jira and to download attachments.
+// This allows the transport to be shared or a fake client to be used in tests.
+func WithHTTPClient(client rutil.Doer) Option {
+	return func(j *JiraAPI) {
+		j.client = client
+		j.downloadClient = client
+	}
+}
+
+// WithTransportConfig creates dedicated HTTP clients for requests and
+// downloads using the given transport settings. The overall Timeout of the
+// settings does not apply to downloads.
+func WithTransportConfig(transportConfig rutil.TransportConfig) Option {
+	return func(j *JiraAPI) {
+		j.client = rutil.NewHTTPClient(transportConfig)
+		j.downloadClient = rutil.NewDownloadClient(transportConfig)
+	}
+}
+
+// WithCacheConfig sets the configuration of the response cache
+func WithCacheConfig(cacheConfig *rutil.CacheConfig) Option {
+	return func(j *JiraAPI) {
+		j.cache = cacheConfig
+	}
+}
+
+// WithLegacySearch enables the deprecated startAt based search endpoint
+func WithLegacySearch(legacy bool) Option {
+	return func(j *JiraAPI) {
+		j.LegacySearch = legacy
+	}
+}
+
+// WithFlavor selects the Jira Cloud or Jira Server / Data Center REST API
+func WithFlavor(flavor APIFlavor) Option {
+	return func(j *JiraAPI) {
+		j.Flavor = flavor
+	}
+}
+
+// WithRequestTimeout sets the deadline of a single request attempt
+func WithRequestTimeout(timeout time.Duration) Option {
+	return func(j *JiraAPI) {
+		j.RequestTimeout = timeout
+	}
+}
+
+// WithRateLimiter sets the limiter pacing the requests. By default all
+// JiraAPI objects share the process-wide rutil.DefaultRateLimiter.
+func WithRateLimiter(limiter *rutil.RateLimiter) Option {
+	return func(j *JiraAPI) {
+		j.limiter = limiter
+	}
+}
+
+// WithRetryPolicy sets the policy for retrying failed requests
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(j *JiraAPI) {
+		j.RetryPolicy = policy
+	}
+}
+
+// WithConcurrency sets the number of parallel requests
+func WithConcurrency(concurrency int) Option {
+	return func(j *JiraAPI) {
+		j.Concurrency = concurrency
+	}
+}
+
+// WithLimit caps the total number of issues returned by a search
+func WithLimit(limit int) Option {
+	return func(j *JiraAPI) {
+		j.Limit = limit
+	}
+}
+
+// WithFields sets the IDs of the fields returned by a search.
+// Use ResolveFieldIDs to translate field names to IDs.
+func WithFields(fields ...string) Option {
+	return func(j *JiraAPI) {
+		j.Fields = fields
+	}
+}
+
+// WithExpand sets the entities expanded in the search results
+func WithExpand(expand ...string) Option {
+	return func(j *JiraAPI) {
+		j.Expand = expand
+	}
+}
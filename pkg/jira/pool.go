@@ -0,0 +1,65 @@
+package jira
+
+import (
+	"context"
+	"sync"
+)
+
+// DefaultConcurrency is the number of parallel requests if nothing else is configured
+const DefaultConcurrency = 10
+
+// runPool calls fn for every index from 0 to n-1 using at most concurrency
+// workers. The first error cancels the context passed to the other calls and
+// is returned once all workers have stopped. Results should be stored by index,
+// so the caller can reassemble them in order.
+func runPool(ctx context.Context, n int, concurrency int, fn func(ctx context.Context, i int) error) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if concurrency > n {
+		concurrency = n
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+
+	indexes := make(chan int)
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if err := fn(ctx, i); err != nil {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+
+	// Feed the indexes until all are processed or the context is cancelled
+feed:
+	for i := 0; i < n; i++ {
+		select {
+		case <-ctx.Done():
+			break feed
+		case indexes <- i:
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	// The parent context may have been cancelled before any worker failed
+	return ctx.Err()
+}
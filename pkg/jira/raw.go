@@ -0,0 +1,18 @@
+package jira
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// decodeRaw converts a raw JSON value as decoded into interface{} into a typed value
+func decodeRaw(in any, out any) error {
+	data, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("error marshalling raw value: %v", err)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("error decoding raw value: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package jira
+
+import (
+	"net/http"
+	"time"
+)
+
+// RetryPolicy defines which failed requests are retried and how often
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts per request. Zero means no limit.
+	MaxAttempts int
+	// RetryableStatuses are the HTTP status codes that are retried.
+	// All other unsuccessful responses fail immediately.
+	RetryableStatuses []int
+	// BaseDelay is the delay after the first failed attempt. It doubles with every attempt.
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between two attempts
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy retries throttled responses and server errors up to 5 times
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 5,
+		RetryableStatuses: []int{
+			http.StatusRequestTimeout,
+			http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+		BaseDelay: time.Second,
+		MaxDelay:  time.Minute,
+	}
+}
+
+// isRetryable reports whether a response with the status code should be retried
+func (p RetryPolicy) isRetryable(statusCode int) bool {
+	for _, s := range p.RetryableStatuses {
+		if s == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+// exhausted reports whether no further attempt may be made
+func (p RetryPolicy) exhausted(attempt int) bool {
+	return p.MaxAttempts > 0 && attempt >= p.MaxAttempts
+}
+
+// nextDelay doubles the delay up to MaxDelay
+func (p RetryPolicy) nextDelay(delay time.Duration) time.Duration {
+	delay *= 2
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay
+}
@@ -1,45 +1,140 @@
 package jira
 
 import (
+	"encoding/json"
 	"fmt"
 	"jira-export/pkg/logger"
+	"jira-export/pkg/output"
+	"net/http"
+	"strings"
 	"time"
 )
 
 // JiraSearchResults
 type JiraSearchResults struct {
-	RequestURL    string        `json:"requestURL"`
-	Expand        string        `json:"expand"`
-	StartAt       int           `json:"startAt"`
-	MaxResults    int           `json:"maxResults"`
-	Total         int           `json:"total"`
-	Issues        []interface{} `json:"issues"`
-	ErrorMessages *[]string     `json:"errorMessages,omitempty"`
+	RequestURL      string        `json:"requestURL"`
+	Expand          string        `json:"expand"`
+	StartAt         int           `json:"startAt"`
+	MaxResults      int           `json:"maxResults"`
+	Total           int           `json:"total"`
+	Issues          []SearchIssue `json:"issues"`
+	ErrorMessages   *[]string     `json:"errorMessages,omitempty"`
+	WarningMessages *[]string     `json:"warningMessages,omitempty"`
+	// NextPageToken and IsLast are returned by the enhanced JQL search endpoint
+	NextPageToken string `json:"nextPageToken,omitempty"`
+	IsLast        bool   `json:"isLast,omitempty"`
 }
 
+// checkMessages logs the warning messages of the response and returns an
+// ErrInvalidJQL error if the response contains error messages
+func (j *JiraSearchResults) checkMessages() error {
+	if j.WarningMessages != nil {
+		for _, warning := range *j.WarningMessages {
+			logger.Logger.Warn("Jira search warning", "warning", warning)
+		}
+	}
+
+	if j.ErrorMessages != nil && len(*j.ErrorMessages) > 0 {
+		apiErr := &APIError{
+			StatusCode:    http.StatusOK,
+			ErrorMessages: *j.ErrorMessages,
+			kind:          ErrInvalidJQL,
+		}
+		if j.WarningMessages != nil {
+			apiErr.WarningMessages = *j.WarningMessages
+		}
+		return apiErr
+	}
+	return nil
+}
+
+// DecodeMode selects how issues which cannot be converted are handled
+type DecodeMode string
+
+const (
+	// DecodeStrict fails on the first issue which cannot be converted
+	DecodeStrict DecodeMode = "strict"
+	// DecodeLenient skips the issues which cannot be converted and returns them as Rejects
+	DecodeLenient DecodeMode = "lenient"
+)
+
+// ParseDecodeMode parses the decode mode from a string. An empty string defaults to lenient.
+func ParseDecodeMode(s string) (DecodeMode, error) {
+	switch DecodeMode(strings.ToLower(s)) {
+	case "", DecodeLenient:
+		return DecodeLenient, nil
+	case DecodeStrict:
+		return DecodeStrict, nil
+	}
+	return "", fmt.Errorf("unknown decode mode %q, expected %s or %s", s, DecodeStrict, DecodeLenient)
+}
+
+// Reject is an issue which could not be converted
+type Reject struct {
+	ID     string          `json:"id,omitempty"`
+	Key    string          `json:"key,omitempty"`
+	Reason string          `json:"reason"`
+	Issue  json.RawMessage `json:"issue"`
+}
+
+// Rejects contains the issues which could not be converted
+type Rejects []Reject
+
+// WriteNDJSON writes one JSON line per rejected issue to a file
+func (r Rejects) WriteNDJSON(filename string) error {
+	return output.WriteNDJSON(filename, r)
+}
+
+// IssuesToJiraIssues converts the issues of the search results. It fails on
+// the first issue which cannot be converted, see ConvertIssues.
 func (j *JiraSearchResults) IssuesToJiraIssues() (issues Issues, err error) {
+	issues, _, _, err = j.ConvertIssues(DecodeStrict)
+	return issues, err
+}
 
-	// Loop through the issues and convert them to JiraIssue objects
-	// and add them to the issues slice in parallel
+// ConvertIssues converts the issues of the search results. In DecodeLenient
+// mode the issues which cannot be converted are returned as Rejects instead
+// of failing the conversion. The search issues which were converted are
+// returned as accepted, so further exports can skip the rejected issues.
+func (j *JiraSearchResults) ConvertIssues(mode DecodeMode) (issues Issues, accepted []SearchIssue, rejects Rejects, err error) {
 	now := time.Now()
 	for _, issue := range j.Issues {
-		// Convert the issue to a JiraIssue object
-		i, err := IssueFromInterface(issue)
+		i, err := convertIssue(issue)
 		if err != nil {
-			return issues, fmt.Errorf("error converting issue to JiraIssue: %v", err)
+			if mode != DecodeLenient {
+				return issues, accepted, rejects, fmt.Errorf("error converting issue to JiraIssue: %v", err)
+			}
+			logger.Logger.Warn("Rejecting issue", "key", issue.Key, "reason", err)
+			rejects = append(rejects, Reject{ID: issue.ID, Key: issue.Key, Reason: err.Error(), Issue: issue.Raw()})
+			continue
 		}
 
-		// Add the JiraIssue object to the issues slice
 		issues = append(issues, i)
+		accepted = append(accepted, issue)
 	}
 
 	logger.Logger.Debug("Processing time for converting issues to JiraIssues", "processing_time", time.Since(now))
 
-	return issues, nil
+	return issues, accepted, rejects, nil
+}
+
+// convertIssue converts a search issue and turns a panic into an error
+func convertIssue(s SearchIssue) (issue Issue, err error) {
+	if s.DecodeError != nil {
+		return issue, s.DecodeError
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("error converting issue %s: %v", s.Key, r)
+		}
+	}()
+	return NewIssue(s)
 }
 
-// WriteCSV writes the JiraSearchResults to a CSV file
-func (j *JiraSearchResults) WriteCSV(filename string) error {
+// WriteCSV writes the JiraSearchResults to a CSV file. If field IDs are given,
+// only the columns derived from these fields are written.
+func (j *JiraSearchResults) WriteCSV(filename string, fields ...string) error {
 	// Convert the JiraSearchResults to a slice of JiraIssue objects
 	issues, err := j.IssuesToJiraIssues()
 	if err != nil {
@@ -47,7 +142,7 @@ func (j *JiraSearchResults) WriteCSV(filename string) error {
 	}
 
 	// Write the issues to a CSV file
-	if err := issues.WriteCSV(filename); err != nil {
+	if err := issues.WriteCSV(filename, fields...); err != nil {
 		return fmt.Errorf("error writing CSV: %v", err)
 	}
 
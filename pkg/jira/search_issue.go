@@ -0,0 +1,215 @@
+package jira
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// SearchIssue is an issue of the search response. The standard fields are
+// decoded into Fields. Every field is also kept as returned by Jira in
+// RawFields, e.g. for the custom fields.
+type SearchIssue struct {
+	ID        string                     `json:"id"`
+	Key       string                     `json:"key"`
+	Self      string                     `json:"self"`
+	Fields    IssueFields                `json:"-"`
+	RawFields map[string]json.RawMessage `json:"fields"`
+	// Changelog is only returned with expand=changelog
+	Changelog *Changelog `json:"changelog,omitempty"`
+
+	// DecodeError is set if the issue could not be decoded. The issues of a
+	// search page are decoded leniently, so one malformed issue does not fail
+	// the page. See JiraSearchResults.ConvertIssues.
+	DecodeError error `json:"-"`
+	// raw is the issue as returned by Jira if it could not be decoded at all
+	raw json.RawMessage
+}
+
+// IssueFields are the standard fields of a SearchIssue
+type IssueFields struct {
+	Summary string `json:"summary"`
+	// Description is Atlassian Document Format on Jira Cloud and wiki markup on Jira Server
+	Description              any              `json:"description"`
+	IssueType                *IssueType       `json:"issuetype"`
+	Status                   *Status          `json:"status"`
+	Assignee                 *JiraIssueUser   `json:"assignee"`
+	Reporter                 *JiraIssueUser   `json:"reporter"`
+	Creator                  *JiraIssueUser   `json:"creator"`
+	Components               []Component      `json:"components"`
+	Created                  string           `json:"created"`
+	Updated                  string           `json:"updated"`
+	ResolutionDate           string           `json:"resolutiondate"`
+	StatusCategoryChangeDate string           `json:"statuscategorychangedate"`
+	Parent                   *LinkedIssue     `json:"parent"`
+	Subtasks                 []LinkedIssue    `json:"subtasks"`
+	IssueLinks               []IssueLinkField `json:"issuelinks"`
+	Attachment               []Attachment     `json:"attachment"`
+	Priority                 *Priority        `json:"priority"`
+	Resolution               *Resolution      `json:"resolution"`
+	Project                  *Project         `json:"project"`
+	Labels                   []string         `json:"labels"`
+	FixVersions              []Version        `json:"fixVersions"`
+	Versions                 []Version        `json:"versions"`
+	DueDate                  string           `json:"duedate"`
+	Environment              any              `json:"environment"`
+	Security                 *struct {
+		Name string `json:"name"`
+	} `json:"security"`
+	Votes *struct {
+		Votes int `json:"votes"`
+	} `json:"votes"`
+	Watches *struct {
+		WatchCount int `json:"watchCount"`
+	} `json:"watches"`
+	// Depending on the Jira version and the selected fields, the time tracking
+	// is returned in the timetracking field, the flat time fields or both
+	TimeTracking         *TimeTracking `json:"timetracking"`
+	TimeOriginalEstimate *int          `json:"timeoriginalestimate"`
+	TimeEstimate         *int          `json:"timeestimate"`
+	TimeSpent            *int          `json:"timespent"`
+}
+
+// timeTracking returns the time tracking of the issue from the timetracking
+// field or the flat time fields. It returns nil if neither is set.
+func (f IssueFields) timeTracking() *TimeTracking {
+	if f.TimeTracking != nil {
+		return f.TimeTracking
+	}
+	if f.TimeOriginalEstimate == nil && f.TimeEstimate == nil && f.TimeSpent == nil {
+		return nil
+	}
+
+	t := &TimeTracking{}
+	if f.TimeOriginalEstimate != nil {
+		t.OriginalEstimateSeconds = *f.TimeOriginalEstimate
+	}
+	if f.TimeEstimate != nil {
+		t.RemainingEstimateSeconds = *f.TimeEstimate
+	}
+	if f.TimeSpent != nil {
+		t.TimeSpentSeconds = *f.TimeSpent
+	}
+	return t
+}
+
+// LinkedIssue is an issue referenced in the parent, subtasks or issuelinks field
+type LinkedIssue struct {
+	ID     string `json:"id"`
+	Key    string `json:"key"`
+	Fields struct {
+		Summary   string     `json:"summary"`
+		Status    *Status    `json:"status"`
+		IssueType *IssueType `json:"issuetype"`
+	} `json:"fields"`
+}
+
+// IssueLinkField is an entry of the issuelinks field. Either the inward or the
+// outward issue is set, the other end of the link is the issue itself.
+type IssueLinkField struct {
+	ID   string `json:"id"`
+	Type struct {
+		Name    string `json:"name"`
+		Inward  string `json:"inward"`
+		Outward string `json:"outward"`
+	} `json:"type"`
+	InwardIssue  *LinkedIssue `json:"inwardIssue"`
+	OutwardIssue *LinkedIssue `json:"outwardIssue"`
+}
+
+// UnmarshalJSON decodes the fields of the issue into Fields and RawFields.
+// Decoding errors are not returned but recorded in DecodeError.
+func (s *SearchIssue) UnmarshalJSON(data []byte) error {
+	type plain SearchIssue
+	if err := json.Unmarshal(data, (*plain)(s)); err != nil {
+		s.DecodeError = fmt.Errorf("error decoding issue: %v", err)
+		s.raw = append(json.RawMessage{}, data...)
+
+		// Try to keep the key to identify the issue
+		var ref struct {
+			ID  any `json:"id"`
+			Key any `json:"key"`
+		}
+		json.Unmarshal(data, &ref)
+		s.ID, _ = ref.ID.(string)
+		s.Key, _ = ref.Key.(string)
+		return nil
+	}
+
+	var typed struct {
+		Fields IssueFields `json:"fields"`
+	}
+	if err := json.Unmarshal(data, &typed); err != nil {
+		s.DecodeError = fmt.Errorf("error decoding fields of %s: %v", s.Key, err)
+		return nil
+	}
+	s.Fields = typed.Fields
+	return nil
+}
+
+// Raw returns the issue as returned by Jira
+func (s SearchIssue) Raw() json.RawMessage {
+	if s.raw != nil {
+		return s.raw
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// Field decodes the raw value of the field with the ID into v. It returns
+// false if the issue has no value for the field.
+func (s SearchIssue) Field(id string, v any) (bool, error) {
+	raw, ok := s.RawFields[id]
+	if !ok || bytes.Equal(raw, []byte("null")) {
+		return false, nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return false, fmt.Errorf("error decoding field %s of %s: %v", id, s.Key, err)
+	}
+	return true, nil
+}
+
+// Ref returns the key, status and issue type of the linked issue
+func (l LinkedIssue) Ref() IssueRef {
+	ref := IssueRef{Key: l.Key}
+	if l.Fields.Status != nil {
+		ref.Status = l.Fields.Status.Name
+	}
+	if l.Fields.IssueType != nil {
+		ref.IssueType = l.Fields.IssueType.Name
+	}
+	return ref
+}
+
+// IsEpic returns true if the linked issue is an epic. Issue types on the
+// hierarchy level above the standard level are epics, even if renamed.
+func (l LinkedIssue) IsEpic() bool {
+	t := l.Fields.IssueType
+	return t != nil && (t.HierarchyLevel == 1 || strings.EqualFold(t.Name, "Epic"))
+}
+
+// Link returns the link as seen from the issue
+func (f IssueLinkField) Link() (IssueLink, bool) {
+	link := IssueLink{ID: f.ID, Type: f.Type.Name}
+
+	target := f.OutwardIssue
+	link.Direction = LinkOutward
+	link.Description = f.Type.Outward
+	if target == nil {
+		target = f.InwardIssue
+		link.Direction = LinkInward
+		link.Description = f.Type.Inward
+	}
+	if target == nil {
+		return link, false
+	}
+
+	ref := target.Ref()
+	link.Key = ref.Key
+	link.Status = ref.Status
+	return link, true
+}
@@ -0,0 +1,94 @@
+package jira
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"jira-export/pkg/logger"
+	"net/http"
+)
+
+const (
+	// navigableFields requests the same fields as the legacy search endpoint
+	// returns by default. The enhanced endpoint only returns the issue IDs otherwise.
+	navigableFields = "*navigable"
+)
+
+// getJQLSearchResults returns the Jira Issues for a given filter using the
+// enhanced JQL search endpoint. The endpoint does not return a total count,
+// so the pages are fetched sequentially by following the nextPageToken.
+// If the context is cancelled, the issues fetched so far are returned together with the error.
+func (j JiraAPI) getJQLSearchResults(ctx context.Context, jql string) (results JiraSearchResults, err error) {
+	// Build the search URL
+	url := j.apiURL("/search/jql")
+
+	// Prepare the cache directory
+	if err := j.cache.PrepareCacheDir(); err != nil {
+		return results, fmt.Errorf("error preparing cache directory: %v", err)
+	}
+
+	// Build the request object
+	req, err := buildSearchRequest(ctx, url, j.secrets, jql, j.pageSize())
+	if err != nil {
+		return results, fmt.Errorf("error building search request: %v", err)
+	}
+	j.setSearchFields(req, navigableFields)
+
+	nextPageToken := ""
+	for page := 1; ; page++ {
+		data, err := j.fetchJQLSearchPage(ctx, req, nextPageToken)
+		if err != nil {
+			results.Issues = j.applyLimit(results.Issues)
+			results.Total = len(results.Issues)
+			return results, fmt.Errorf("error fetching page %d: %w", page, err)
+		}
+
+		results.Issues = append(results.Issues, data.Issues...)
+		logger.Logger.Debug("Fetched search page", "page", page, "issues", len(results.Issues))
+
+		if data.IsLast || data.NextPageToken == "" {
+			break
+		}
+		if j.Limit > 0 && len(results.Issues) >= j.Limit {
+			logger.Logger.Info("Limit reached", "limit", j.Limit)
+			break
+		}
+		nextPageToken = data.NextPageToken
+	}
+
+	results.Issues = j.applyLimit(results.Issues)
+	results.MaxResults = j.MaxResults
+	results.Total = len(results.Issues)
+	results.IsLast = true
+
+	return results, nil
+}
+
+// fetchJQLSearchPage fetches a single page of the enhanced JQL search
+func (j JiraAPI) fetchJQLSearchPage(ctx context.Context, req *http.Request, nextPageToken string) (data JiraSearchResults, err error) {
+	r := req.Clone(ctx)
+	if nextPageToken != "" {
+		q := r.URL.Query()
+		q.Set("nextPageToken", nextPageToken)
+		r.URL.RawQuery = q.Encode()
+	}
+
+	// Send the request with incremental backoff using the CachedRequest function
+	resp, err := j.sendRequestWithBackoff(ctx, r)
+	if err != nil {
+		return data, fmt.Errorf("error sending search request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Decode the response body
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return data, HandleJSONDecodeError(err, resp)
+	}
+
+	// The response may include an "errorMessages" field in case of a wrong search query
+	if err := data.checkMessages(); err != nil {
+		return data, err
+	}
+
+	return data, nil
+}
@@ -0,0 +1,246 @@
+package jira
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"jira-export/pkg/logger"
+	"jira-export/pkg/output"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// OrganizationsFieldSchema is the custom schema of the customer organizations field
+const OrganizationsFieldSchema = "com.atlassian.servicedesk:sd-customer-organizations"
+
+// SLA cycle states
+const (
+	SLACycleOngoing   = "ongoing"
+	SLACycleCompleted = "completed"
+)
+
+// ServiceRequest contains the Jira Service Management data of an issue
+type ServiceRequest struct {
+	IssueKey      string          `json:"issueKey"`
+	RequestType   string          `json:"requestType"`
+	Participants  []JiraIssueUser `json:"participants"`
+	Organizations []string        `json:"organizations"`
+	SLAs          []SLA           `json:"slas"`
+}
+
+// SLA is a service level agreement of a request, e.g. "Time to first response"
+type SLA struct {
+	ID              string     `json:"id"`
+	Name            string     `json:"name"`
+	OngoingCycle    *SLACycle  `json:"ongoingCycle,omitempty"`
+	CompletedCycles []SLACycle `json:"completedCycles"`
+}
+
+// SLACycle is a period in which an SLA was measured
+type SLACycle struct {
+	StartTime           SLADate     `json:"startTime"`
+	StopTime            SLADate     `json:"stopTime"`
+	BreachTime          SLADate     `json:"breachTime"`
+	Breached            bool        `json:"breached"`
+	Paused              bool        `json:"paused"`
+	WithinCalendarHours bool        `json:"withinCalendarHours"`
+	GoalDuration        SLADuration `json:"goalDuration"`
+	ElapsedTime         SLADuration `json:"elapsedTime"`
+	RemainingTime       SLADuration `json:"remainingTime"`
+}
+
+// SLADate is a point in time of an SLA cycle
+type SLADate struct {
+	ISO8601     string `json:"iso8601,omitempty"`
+	EpochMillis int64  `json:"epochMillis,omitempty"`
+}
+
+// SLADuration is a duration of an SLA cycle. The remaining time is negative
+// if the SLA is breached.
+type SLADuration struct {
+	Millis   int64  `json:"millis"`
+	Friendly string `json:"friendly,omitempty"`
+}
+
+// ServiceRequests contains the service requests of several issues
+type ServiceRequests []ServiceRequest
+
+// serviceDeskPage is a page of the service desk REST API
+type serviceDeskPage[T any] struct {
+	Size       int  `json:"size"`
+	Start      int  `json:"start"`
+	Limit      int  `json:"limit"`
+	IsLastPage bool `json:"isLastPage"`
+	Values     []T  `json:"values"`
+}
+
+// serviceDeskURL returns the URL of a service desk REST API resource, e.g. serviceDeskURL("/request/TEST-1")
+func (j JiraAPI) serviceDeskURL(path string) string {
+	return fmt.Sprintf("%s/rest/servicedeskapi%s", strings.TrimRight(j.secrets.URL, "/"), path)
+}
+
+// getServiceDeskValues fetches all pages of a service desk REST API resource
+func getServiceDeskValues[T any](ctx context.Context, j JiraAPI, u string) ([]T, error) {
+	values := []T{}
+	for start := 0; ; {
+		query := url.Values{}
+		query.Set("start", strconv.Itoa(start))
+		query.Set("limit", "50")
+
+		var page serviceDeskPage[T]
+		if err := j.getJSON(ctx, u+"?"+query.Encode(), &page); err != nil {
+			return nil, err
+		}
+		values = append(values, page.Values...)
+		start += len(page.Values)
+
+		if page.IsLastPage || len(page.Values) == 0 {
+			return values, nil
+		}
+	}
+}
+
+// GetServiceRequest returns the request type, participants and SLAs of an issue
+func (j JiraAPI) GetServiceRequest(ctx context.Context, key string) (ServiceRequest, error) {
+	r := ServiceRequest{IssueKey: key}
+	path := "/request/" + url.PathEscape(key)
+
+	var request struct {
+		RequestType struct {
+			Name string `json:"name"`
+		} `json:"requestType"`
+	}
+	if err := j.getJSON(ctx, j.serviceDeskURL(path+"?expand=requestType"), &request); err != nil {
+		return r, fmt.Errorf("error getting request %s: %w", key, err)
+	}
+	r.RequestType = request.RequestType.Name
+
+	participants, err := getServiceDeskValues[JiraIssueUser](ctx, j, j.serviceDeskURL(path+"/participant"))
+	if err != nil {
+		return r, fmt.Errorf("error getting participants of %s: %w", key, err)
+	}
+	r.Participants = participants
+
+	slas, err := getServiceDeskValues[SLA](ctx, j, j.serviceDeskURL(path+"/sla"))
+	if err != nil {
+		return r, fmt.Errorf("error getting SLAs of %s: %w", key, err)
+	}
+	r.SLAs = slas
+
+	return r, nil
+}
+
+// GetServiceRequests returns the service requests of the issues. The
+// organizations are read from the organization fields with the IDs. Issues
+// which are not service requests are skipped.
+func (j JiraAPI) GetServiceRequests(ctx context.Context, issues []SearchIssue, organizationFieldIDs []string) (ServiceRequests, error) {
+	requests := make([]*ServiceRequest, len(issues))
+	err := runPool(ctx, len(issues), j.Concurrency, func(ctx context.Context, i int) error {
+		key := issues[i].Key
+		r, err := j.GetServiceRequest(ctx, key)
+		if errors.Is(err, ErrNotFound) {
+			logger.Logger.Debug("Issue is no service request", "key", key)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		r.Organizations, err = issueOrganizations(issues[i], organizationFieldIDs)
+		if err != nil {
+			return err
+		}
+		requests[i] = &r
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	found := ServiceRequests{}
+	for _, r := range requests {
+		if r != nil {
+			found = append(found, *r)
+		}
+	}
+	return found, nil
+}
+
+// issueOrganizations returns the names of the customer organizations of an issue
+func issueOrganizations(issue SearchIssue, fieldIDs []string) ([]string, error) {
+	names := []string{}
+	for _, id := range fieldIDs {
+		var organizations []struct {
+			Name string `json:"name"`
+		}
+		if _, err := issue.Field(id, &organizations); err != nil {
+			return nil, err
+		}
+		for _, o := range organizations {
+			names = append(names, o.Name)
+		}
+	}
+	return names, nil
+}
+
+// Users returns the participants of the requests
+func (s ServiceRequests) Users() []JiraIssueUser {
+	users := []JiraIssueUser{}
+	for _, r := range s {
+		users = append(users, r.Participants...)
+	}
+	return UniqueUsers(users)
+}
+
+// WriteCSV writes one row per request to a CSV file
+func (s ServiceRequests) WriteCSV(filename string) error {
+	header := []string{"key", "requestType", "participants", "organizations"}
+	rows := make([][]string, 0, len(s))
+	for _, r := range s {
+		participants := make([]string, len(r.Participants))
+		for n, p := range r.Participants {
+			participants[n] = p.DisplayName
+		}
+		rows = append(rows, []string{r.IssueKey, r.RequestType, strings.Join(participants, "|"), strings.Join(r.Organizations, "|")})
+	}
+	return output.WriteCSV(filename, header, rows)
+}
+
+// WriteSLACSV writes one row per request, SLA and cycle to a CSV file. The
+// durations are written in seconds.
+func (s ServiceRequests) WriteSLACSV(filename string) error {
+	header := []string{"key", "sla", "cycle", "startTime", "stopTime", "breachTime", "breached", "paused", "goalSeconds", "elapsedSeconds", "remainingSeconds"}
+	rows := [][]string{}
+	for _, r := range s {
+		for _, sla := range r.SLAs {
+			cycles := []SLACycle{}
+			states := []string{}
+			for _, c := range sla.CompletedCycles {
+				cycles = append(cycles, c)
+				states = append(states, SLACycleCompleted)
+			}
+			if sla.OngoingCycle != nil {
+				cycles = append(cycles, *sla.OngoingCycle)
+				states = append(states, SLACycleOngoing)
+			}
+
+			for n, c := range cycles {
+				rows = append(rows, []string{
+					r.IssueKey,
+					sla.Name,
+					states[n],
+					c.StartTime.ISO8601,
+					c.StopTime.ISO8601,
+					c.BreachTime.ISO8601,
+					strconv.FormatBool(c.Breached),
+					strconv.FormatBool(c.Paused),
+					strconv.FormatInt(c.GoalDuration.Millis/1000, 10),
+					strconv.FormatInt(c.ElapsedTime.Millis/1000, 10),
+					strconv.FormatInt(c.RemainingTime.Millis/1000, 10),
+				})
+			}
+		}
+	}
+	return output.WriteCSV(filename, header, rows)
+}
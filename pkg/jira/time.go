@@ -0,0 +1,121 @@
+package jira
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TimeLayout is the layout of the timestamps returned by Jira, e.g. "2024-01-02T10:11:12.000+0100"
+const TimeLayout = "2006-01-02T15:04:05.000-0700"
+
+// Named date formats of the CSV export
+const (
+	DateFormatJira     = "jira"
+	DateFormatRFC3339  = "rfc3339"
+	DateFormatDate     = "date"
+	DateFormatDateTime = "datetime"
+)
+
+// dateFormats maps the named date formats to their layouts
+var dateFormats = map[string]string{
+	DateFormatJira:     TimeLayout,
+	DateFormatRFC3339:  time.RFC3339,
+	DateFormatDate:     time.DateOnly,
+	DateFormatDateTime: time.DateTime,
+}
+
+// ParseTime parses a Jira timestamp
+func ParseTime(s string) (time.Time, error) {
+	return time.Parse(TimeLayout, s)
+}
+
+// DateLayout returns the layout of a named date format. Any other value is
+// used as a Go time layout, e.g. "02.01.2006 15:04". An empty format is the Jira layout.
+func DateLayout(format string) string {
+	if format == "" {
+		return TimeLayout
+	}
+	if layout, ok := dateFormats[strings.ToLower(format)]; ok {
+		return layout
+	}
+	return format
+}
+
+// Time is a timestamp of an issue. It is marshalled to JSON as RFC 3339 or
+// null if it is not set.
+type Time struct {
+	time.Time
+}
+
+// parseIssueTime parses an optional Jira timestamp of an issue field
+func parseIssueTime(field string, s string) (Time, error) {
+	if s == "" {
+		return Time{}, nil
+	}
+	t, err := ParseTime(s)
+	if err != nil {
+		return Time{}, fmt.Errorf("error parsing %s: %v", field, err)
+	}
+	return Time{t}, nil
+}
+
+// MarshalJSON encodes the time as RFC 3339 or null if it is not set
+func (t Time) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(t.Format(time.RFC3339Nano))
+}
+
+// UnmarshalJSON decodes a RFC 3339 or Jira timestamp or null
+func (t *Time) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		*t = Time{}
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if s == "" {
+		*t = Time{}
+		return nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		if parsed, err = ParseTime(s); err != nil {
+			return fmt.Errorf("error parsing time %q: %v", s, err)
+		}
+	}
+	t.Time = parsed
+	return nil
+}
+
+// Format formats the time with the layout or returns an empty string if it is not set
+func (t Time) Format(layout string) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Time.Format(layout)
+}
+
+// In returns the time in the location. Unset times and a nil location are kept.
+func (t Time) In(loc *time.Location) Time {
+	if t.IsZero() || loc == nil {
+		return t
+	}
+	return Time{t.Time.In(loc)}
+}
+
+// days returns the number of days between from and to, or an empty string if either is not set
+func days(from Time, to Time) string {
+	if from.IsZero() || to.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("%.1f", to.Sub(from.Time).Hours()/24)
+}
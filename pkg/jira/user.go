@@ -0,0 +1,180 @@
+package jira
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"jira-export/pkg/logger"
+	"jira-export/pkg/output"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// User is a Jira user as returned by the user API
+type User struct {
+	JiraIssueUser
+	// AccountType is "atlassian", "app" or "customer" on Jira Cloud
+	AccountType string   `json:"accountType,omitempty"`
+	TimeZone    string   `json:"timeZone,omitempty"`
+	Locale      string   `json:"locale,omitempty"`
+	Groups      []string `json:"groups,omitempty"`
+}
+
+// userGroup is the expanded groups object of a user
+type userGroup struct {
+	Items []struct {
+		Name string `json:"name"`
+	} `json:"items"`
+}
+
+// rawUser is a user as returned by the user API. The expanded groups
+// shadow the group names of the embedded User.
+type rawUser struct {
+	User
+	RawGroups userGroup `json:"groups"`
+}
+
+// Users contains the resolved users
+type Users []User
+
+// ReferencedUsers returns the unique assignees, reporters and creators of the
+// issues and the users selected in user picker custom fields, sorted by
+// display name. The users of comments, worklogs, changelogs and service
+// requests are returned by their Users methods, see UniqueUsers.
+func (i Issues) ReferencedUsers() []JiraIssueUser {
+	users := []JiraIssueUser{}
+	for _, issue := range i {
+		users = append(users, issue.Assignee, issue.Reporter, issue.Creator)
+		for _, value := range issue.CustomFields {
+			users = append(users, customFieldUsers(value)...)
+		}
+	}
+	return UniqueUsers(users)
+}
+
+// customFieldUsers returns the users of a user picker custom field value.
+// Users are told apart from other objects by their account ID or their self URL.
+func customFieldUsers(value any) []JiraIssueUser {
+	switch v := value.(type) {
+	case []any:
+		users := []JiraIssueUser{}
+		for _, item := range v {
+			users = append(users, customFieldUsers(item)...)
+		}
+		return users
+	case map[string]any:
+		self, _ := v["self"].(string)
+		if _, ok := v["accountId"].(string); ok || strings.Contains(self, "/user?") {
+			var user JiraIssueUser
+			if err := user.FromInterface(v); err == nil {
+				return []JiraIssueUser{user}
+			}
+		}
+	}
+	return nil
+}
+
+// UniqueUsers merges the lists of users into one list of unique users sorted
+// by display name. Users without an ID, e.g. unassigned issues, are skipped.
+func UniqueUsers(lists ...[]JiraIssueUser) []JiraIssueUser {
+	users := []JiraIssueUser{}
+	seen := map[string]bool{}
+	for _, list := range lists {
+		for _, u := range list {
+			if u.ID() == "" || seen[u.ID()] {
+				continue
+			}
+			seen[u.ID()] = true
+			users = append(users, u)
+		}
+	}
+
+	sort.Slice(users, func(a, b int) bool {
+		return users[a].DisplayName < users[b].DisplayName
+	})
+	return users
+}
+
+// GetUser returns the user details of the referenced user. With groups, the
+// names of the groups the user belongs to are included.
+func (j JiraAPI) GetUser(ctx context.Context, ref JiraIssueUser, withGroups bool) (User, error) {
+	query := url.Values{}
+	switch {
+	case ref.AccountID != "":
+		query.Set("accountId", ref.AccountID)
+	case ref.Key != "":
+		query.Set("key", ref.Key)
+	default:
+		query.Set("username", ref.Name)
+	}
+	if withGroups {
+		query.Set("expand", "groups")
+	}
+
+	var raw rawUser
+	if err := j.getJSON(ctx, j.apiURL("/user?"+query.Encode()), &raw); err != nil {
+		return User{}, fmt.Errorf("error getting user %s: %w", ref.ID(), err)
+	}
+
+	user := raw.User
+	for _, g := range raw.RawGroups.Items {
+		user.Groups = append(user.Groups, g.Name)
+	}
+	return user, nil
+}
+
+// GetUsers returns the user details of the referenced users. Users which no
+// longer exist keep the details of the reference.
+func (j JiraAPI) GetUsers(ctx context.Context, refs []JiraIssueUser, withGroups bool) (Users, error) {
+	users := make(Users, len(refs))
+	err := runPool(ctx, len(refs), j.Concurrency, func(ctx context.Context, i int) error {
+		user, err := j.GetUser(ctx, refs[i], withGroups)
+		if errors.Is(err, ErrNotFound) {
+			logger.Logger.Warn("User not found", "user", refs[i].ID(), "name", refs[i].DisplayName)
+			users[i] = User{JiraIssueUser: refs[i]}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		users[i] = user
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// WriteCSV writes one row per user to a CSV file. The email address is only
+// available if the privacy settings of the user allow it.
+func (u Users) WriteCSV(filename string) error {
+	header := []string{"id", "name", "displayName", "emailAddress", "accountType", "timeZone", "active"}
+	rows := make([][]string, 0, len(u))
+	for _, user := range u {
+		rows = append(rows, []string{
+			user.ID(),
+			user.Name,
+			user.DisplayName,
+			user.EmailAddress,
+			user.AccountType,
+			user.TimeZone,
+			strconv.FormatBool(user.Active),
+		})
+	}
+	return output.WriteCSV(filename, header, rows)
+}
+
+// WriteGroupsCSV writes one row per user and group to a CSV file
+func (u Users) WriteGroupsCSV(filename string) error {
+	header := []string{"id", "displayName", "group"}
+	rows := [][]string{}
+	for _, user := range u {
+		for _, g := range user.Groups {
+			rows = append(rows, []string{user.ID(), user.DisplayName, g})
+		}
+	}
+	return output.WriteCSV(filename, header, rows)
+}
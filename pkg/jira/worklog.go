@@ -0,0 +1,307 @@
+package jira
+
+import (
+	"context"
+	"fmt"
+	"jira-export/pkg/logger"
+	"jira-export/pkg/output"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Worklog is the time booked by a user on an issue
+type Worklog struct {
+	IssueKey         string        `json:"issueKey"`
+	IssueID          string        `json:"issueId"`
+	ID               string        `json:"id"`
+	Author           JiraIssueUser `json:"author"`
+	UpdateAuthor     JiraIssueUser `json:"updateAuthor"`
+	Comment          string        `json:"comment"`
+	Started          string        `json:"started"`
+	TimeSpent        string        `json:"timeSpent"`
+	TimeSpentSeconds int           `json:"timeSpentSeconds"`
+	Created          string        `json:"created"`
+	Updated          string        `json:"updated"`
+}
+
+// rawWorklog is a worklog as returned by Jira with the comment as ADF or wiki markup
+type rawWorklog struct {
+	Worklog
+	Comment any `json:"comment"`
+}
+
+// toWorklog renders the comment of the raw worklog
+func (w rawWorklog) toWorklog(key string) Worklog {
+	worklog := w.Worklog
+	worklog.IssueKey = key
+	worklog.Comment = renderRichText(w.Comment)
+	return worklog
+}
+
+// worklogPage is a page of the issue worklog endpoint
+type worklogPage struct {
+	StartAt    int          `json:"startAt"`
+	MaxResults int          `json:"maxResults"`
+	Total      int          `json:"total"`
+	Worklogs   []rawWorklog `json:"worklogs"`
+}
+
+// worklogChangePage is a page of the updated worklogs endpoint
+type worklogChangePage struct {
+	Values []struct {
+		WorklogID   int   `json:"worklogId"`
+		UpdatedTime int64 `json:"updatedTime"`
+	} `json:"values"`
+	Since    int64  `json:"since"`
+	Until    int64  `json:"until"`
+	LastPage bool   `json:"lastPage"`
+	NextPage string `json:"nextPage"`
+}
+
+// worklogListBatchSize is the maximum number of worklogs fetched per request from /worklog/list
+const worklogListBatchSize = 1000
+
+// Worklogs contains the worklogs of several issues
+type Worklogs []Worklog
+
+// GetWorklogs returns the worklogs of the issues with the keys. The issues
+// are processed in parallel and the worklogs are returned in issue order.
+func (j JiraAPI) GetWorklogs(ctx context.Context, keys []string) (Worklogs, error) {
+	worklogs := make([]Worklogs, len(keys))
+	err := runPool(ctx, len(keys), j.Concurrency, func(ctx context.Context, i int) error {
+		w, err := j.GetIssueWorklogs(ctx, keys[i])
+		if err != nil {
+			return err
+		}
+		worklogs[i] = w
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	all := Worklogs{}
+	for _, w := range worklogs {
+		all = append(all, w...)
+	}
+	return all, nil
+}
+
+// GetIssueWorklogs returns all worklogs of an issue following the pagination
+func (j JiraAPI) GetIssueWorklogs(ctx context.Context, key string) (Worklogs, error) {
+	worklogs := Worklogs{}
+	for startAt := 0; ; {
+		u := fmt.Sprintf("%s?startAt=%s&maxResults=1000", j.apiURL("/issue/"+url.PathEscape(key)+"/worklog"), strconv.Itoa(startAt))
+
+		var page worklogPage
+		if err := j.getJSON(ctx, u, &page); err != nil {
+			return nil, fmt.Errorf("error getting worklogs of %s: %w", key, err)
+		}
+
+		for _, w := range page.Worklogs {
+			worklogs = append(worklogs, w.toWorklog(key))
+		}
+		startAt += len(page.Worklogs)
+
+		if len(page.Worklogs) == 0 || startAt >= page.Total {
+			return worklogs, nil
+		}
+	}
+}
+
+// GetUpdatedWorklogs returns all worklogs created or updated since the given
+// time using the incremental /worklog/updated and /worklog/list endpoints.
+// The worklogs only carry the issue ID, the issue key is left empty.
+func (j JiraAPI) GetUpdatedWorklogs(ctx context.Context, since time.Time) (Worklogs, error) {
+	ids := []int{}
+	u := fmt.Sprintf("%s?since=%d", j.apiURL("/worklog/updated"), since.UnixMilli())
+	for {
+		var page worklogChangePage
+		if err := j.getJSON(ctx, u, &page); err != nil {
+			return nil, fmt.Errorf("error getting updated worklogs: %w", err)
+		}
+		for _, v := range page.Values {
+			ids = append(ids, v.WorklogID)
+		}
+
+		if page.LastPage || page.NextPage == "" {
+			break
+		}
+		u = page.NextPage
+	}
+
+	worklogs := Worklogs{}
+	for start := 0; start < len(ids); start += worklogListBatchSize {
+		end := start + worklogListBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		var batch []rawWorklog
+		body := map[string][]int{"ids": ids[start:end]}
+		if err := j.postJSON(ctx, j.apiURL("/worklog/list"), body, &batch); err != nil {
+			return nil, fmt.Errorf("error getting worklogs: %w", err)
+		}
+		for _, w := range batch {
+			worklogs = append(worklogs, w.toWorklog(""))
+		}
+	}
+	return worklogs, nil
+}
+
+// ForIssues returns the worklogs of the issues and sets their issue keys
+func (w Worklogs) ForIssues(issues Issues) Worklogs {
+	keys := map[string]string{}
+	for _, issue := range issues {
+		keys[issue.ID] = issue.Key
+	}
+
+	filtered := Worklogs{}
+	for _, worklog := range w {
+		if key, ok := keys[worklog.IssueID]; ok {
+			worklog.IssueKey = key
+			filtered = append(filtered, worklog)
+		}
+	}
+	return filtered
+}
+
+// Users returns the authors and update authors of the worklogs
+func (w Worklogs) Users() []JiraIssueUser {
+	users := []JiraIssueUser{}
+	for _, worklog := range w {
+		users = append(users, worklog.Author, worklog.UpdateAuthor)
+	}
+	return UniqueUsers(users)
+}
+
+// WriteCSV writes one row per worklog to a CSV file
+func (w Worklogs) WriteCSV(filename string) error {
+	header := []string{"key", "id", "author", "started", "timeSpent", "timeSpentSeconds", "created", "updated", "comment"}
+	rows := make([][]string, 0, len(w))
+	for _, worklog := range w {
+		rows = append(rows, []string{
+			worklog.IssueKey,
+			worklog.ID,
+			worklog.Author.DisplayName,
+			worklog.Started,
+			worklog.TimeSpent,
+			strconv.Itoa(worklog.TimeSpentSeconds),
+			worklog.Created,
+			worklog.Updated,
+			worklog.Comment,
+		})
+	}
+	return output.WriteCSV(filename, header, rows)
+}
+
+const (
+	// TimesheetByEpic groups the booked hours by the epic of the issue
+	TimesheetByEpic = "epic"
+	// TimesheetByComponent groups the booked hours by the components of the issue
+	TimesheetByComponent = "component"
+
+	// timesheetNone is the group of issues without an epic or component
+	timesheetNone = "(none)"
+)
+
+// TimesheetEntry contains the hours booked by a user in a week on an epic or component
+type TimesheetEntry struct {
+	// UserID is the account ID on Jira Cloud or the user key on Jira Server
+	UserID string  `json:"userId"`
+	User   string  `json:"user"`
+	Week   string  `json:"week"`
+	Group  string  `json:"group"`
+	Hours  float64 `json:"hours"`
+}
+
+// Timesheet contains the booked hours per user, week and epic or component
+type Timesheet []TimesheetEntry
+
+// Timesheet pivots the worklogs by user, ISO week and the epic or components
+// of the issue. The hours of an issue with several components are split
+// evenly between the components. Users are told apart by their ID, so users
+// with the same display name are not merged. Worklogs with an invalid start
+// date are skipped.
+func (w Worklogs) Timesheet(issues Issues, groupBy string) (Timesheet, error) {
+	if groupBy != TimesheetByEpic && groupBy != TimesheetByComponent {
+		return nil, fmt.Errorf("unknown timesheet grouping %q", groupBy)
+	}
+
+	byKey := map[string]Issue{}
+	for _, issue := range issues {
+		byKey[issue.Key] = issue
+	}
+
+	type cell struct{ user, week, group string }
+	hours := map[cell]float64{}
+	names := map[string]string{}
+	for _, worklog := range w {
+		started, err := ParseTime(worklog.Started)
+		if err != nil {
+			logger.Logger.Warn("Skipping worklog with invalid start date", "key", worklog.IssueKey, "id", worklog.ID, "error", err)
+			continue
+		}
+		year, week := started.ISOWeek()
+		weekName := fmt.Sprintf("%d-W%02d", year, week)
+
+		// Anonymized or app users may have no ID
+		userID := worklog.Author.ID()
+		if userID == "" {
+			userID = worklog.Author.DisplayName
+		}
+		names[userID] = worklog.Author.DisplayName
+
+		groups := worklogGroups(byKey[worklog.IssueKey], groupBy)
+		for _, group := range groups {
+			c := cell{userID, weekName, group}
+			hours[c] += float64(worklog.TimeSpentSeconds) / 3600 / float64(len(groups))
+		}
+	}
+
+	timesheet := make(Timesheet, 0, len(hours))
+	for c, h := range hours {
+		timesheet = append(timesheet, TimesheetEntry{UserID: c.user, User: names[c.user], Week: c.week, Group: c.group, Hours: h})
+	}
+	sort.Slice(timesheet, func(a, b int) bool {
+		x, y := timesheet[a], timesheet[b]
+		if x.User != y.User {
+			return x.User < y.User
+		}
+		if x.UserID != y.UserID {
+			return x.UserID < y.UserID
+		}
+		if x.Week != y.Week {
+			return x.Week < y.Week
+		}
+		return x.Group < y.Group
+	})
+	return timesheet, nil
+}
+
+// worklogGroups returns the epic or the components of the issue
+func worklogGroups(issue Issue, groupBy string) []string {
+	if groupBy == TimesheetByComponent {
+		if len(issue.Components) == 0 {
+			return []string{timesheetNone}
+		}
+		return issue.Components
+	}
+
+	if issue.Epic == "" {
+		return []string{timesheetNone}
+	}
+	return []string{issue.Epic}
+}
+
+// WriteCSV writes one row per user, week and group to a CSV file
+func (t Timesheet) WriteCSV(filename string, groupBy string) error {
+	header := []string{"userId", "user", "week", groupBy, "hours"}
+	rows := make([][]string, 0, len(t))
+	for _, e := range t {
+		rows = append(rows, []string{e.UserID, e.User, e.Week, e.Group, strconv.FormatFloat(e.Hours, 'f', 2, 64)})
+	}
+	return output.WriteCSV(filename, header, rows)
+}
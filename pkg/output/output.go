@@ -1,6 +1,8 @@
 package output
 
 import (
+	"encoding/csv"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -68,3 +70,76 @@ func ListFiles(dir string, glob string) ([]string, error) {
 
 	return files, nil
 }
+
+// createParentDir creates all parent directories of the file if they don't exist
+func createParentDir(filename string) error {
+	dir := filepath.Dir(filename)
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("error creating directory: %v", err)
+		}
+	}
+	return nil
+}
+
+// WriteJSON marshals the value and writes it to a file
+func WriteJSON(filename string, v any) error {
+	if err := createParentDir(filename); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("error marshalling json: %v", err)
+	}
+
+	if err := os.WriteFile(filename, data, 0644); err != nil {
+		return fmt.Errorf("error writing to file: %v", err)
+	}
+	return nil
+}
+
+// WriteCSV writes the header and the rows to a CSV file
+func WriteCSV(filename string, header []string, rows [][]string) error {
+	if err := createParentDir(filename); err != nil {
+		return err
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("error creating file: %v", err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("error writing header: %v", err)
+	}
+	if err := writer.WriteAll(rows); err != nil {
+		return fmt.Errorf("error writing rows: %v", err)
+	}
+
+	return nil
+}
+
+// WriteNDJSON writes one JSON encoded line per value to a file
+func WriteNDJSON[T any](filename string, values []T) error {
+	if err := createParentDir(filename); err != nil {
+		return err
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("error creating file: %v", err)
+	}
+	defer file.Close()
+
+	// The encoder terminates every value with a newline
+	encoder := json.NewEncoder(file)
+	for _, v := range values {
+		if err := encoder.Encode(v); err != nil {
+			return fmt.Errorf("error encoding json: %v", err)
+		}
+	}
+	return nil
+}
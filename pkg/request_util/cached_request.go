@@ -4,6 +4,7 @@ import (
 	"crypto/sha1"
 	"encoding/hex"
 	"fmt"
+	"io"
 	"jira-export/pkg/logger"
 	"jira-export/pkg/output"
 	"net/http"
@@ -16,7 +17,9 @@ var (
 
 type CachedRequest struct {
 	*http.Request
-	hash string
+	// Client is used to send the request. DefaultClient is used if nil.
+	Client Doer
+	hash   string
 }
 
 type CacheConfig struct {
@@ -24,9 +27,14 @@ type CacheConfig struct {
 	Debug     bool
 }
 
-// NewCachedRequest creates a new CachedRequest object
-func NewCachedRequest(req *http.Request) *CachedRequest {
-	return &CachedRequest{Request: req}
+// NewCachedRequest creates a new CachedRequest object. An optional client
+// can be passed to send the request, otherwise DefaultClient is used.
+func NewCachedRequest(req *http.Request, client ...Doer) *CachedRequest {
+	cr := &CachedRequest{Request: req}
+	if len(client) > 0 {
+		cr.Client = client[0]
+	}
+	return cr
 }
 
 // PrepareCacheDir creates the cache directory if it does not exist
@@ -41,7 +49,10 @@ func (config *CacheConfig) PrepareCacheDir() error {
 
 // SendRequest sends the HTTP request and returns the response
 func (req *CachedRequest) SendRequest() (*http.Response, error) {
-	client := &http.Client{}
+	client := req.Client
+	if client == nil {
+		client = DefaultClient
+	}
 	resp, err := client.Do(req.Request)
 	if err != nil {
 		return nil, fmt.Errorf("error sending request: %v", err)
@@ -49,13 +60,21 @@ func (req *CachedRequest) SendRequest() (*http.Response, error) {
 	return resp, nil
 }
 
-// GetCacheID returns the cache ID for the request
+// GetCacheID returns the cache ID for the request. For requests with a body,
+// e.g. POST requests, the method and the body are part of the cache ID.
 func (req *CachedRequest) GetCacheID() string {
 	if req.hash == "" {
 		// Create the cache filename from the request URL and query parameters
 		// and encode it as sha256
 		hash := sha1.New()
 		hash.Write([]byte(req.URL.String()))
+		if req.GetBody != nil {
+			if body, err := req.GetBody(); err == nil {
+				hash.Write([]byte(req.Method))
+				io.Copy(hash, body)
+				body.Close()
+			}
+		}
 		req.hash = hex.EncodeToString(hash.Sum(nil))
 	}
 
@@ -67,6 +86,12 @@ func (req *CachedRequest) GetCacheFile(config *CacheConfig) string {
 	return fmt.Sprintf("%s/%x.json", config.OutputDir, req.GetCacheID())
 }
 
+// IsCached reports whether a cache file exists for the request
+func (req *CachedRequest) IsCached(config *CacheConfig) bool {
+	_, err := os.Stat(req.GetCacheFile(config))
+	return err == nil
+}
+
 // ClearCacheFile deletes the cache file
 func (req *CachedRequest) ClearCacheFile(config *CacheConfig) error {
 	logger.Logger.Info("Clearing cache file", "cacheFile", req.GetCacheFile(config))
@@ -120,6 +145,12 @@ func (req *CachedRequest) Cache(config ...*CacheConfig) (*http.Response, error)
 		return nil, fmt.Errorf("error sending request: %v", err)
 	}
 
+	// Only successful responses are cached, otherwise a rate limited
+	// or failed response would be returned from the cache on retry
+	if resp.StatusCode != http.StatusOK {
+		return resp, nil
+	}
+
 	// Store the response body in a file
 	if debug {
 		logger.Logger.Info("Storing response body into cache file", "cacheFile", cacheFile)
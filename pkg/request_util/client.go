@@ -0,0 +1,92 @@
+package request_util
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// Doer is the interface implemented by *http.Client. It allows the HTTP
+// client used for requests to be replaced, e.g. by a fake in tests.
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// TransportConfig contains the tunables of the shared HTTP transport
+type TransportConfig struct {
+	// Timeout is the overall time limit for a single request including
+	// reading the response body. Zero means no timeout.
+	Timeout               time.Duration
+	DialTimeout           time.Duration
+	KeepAlive             time.Duration
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration
+	IdleConnTimeout       time.Duration
+	MaxIdleConns          int
+	MaxIdleConnsPerHost   int
+	MaxConnsPerHost       int
+	// DisableCompression disables the transparent gzip compression
+	// of the transport.
+	DisableCompression bool
+}
+
+// DefaultTransportConfig returns the transport settings used when nothing
+// else is configured
+func DefaultTransportConfig() TransportConfig {
+	return TransportConfig{
+		Timeout:               2 * time.Minute,
+		DialTimeout:           30 * time.Second,
+		KeepAlive:             30 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: time.Minute,
+		IdleConnTimeout:       90 * time.Second,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   20,
+	}
+}
+
+// NewTransport creates a new http.Transport from the given config.
+// Keep-alives are always enabled so connections are reused between requests.
+func NewTransport(config TransportConfig) *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   config.DialTimeout,
+		KeepAlive: config.KeepAlive,
+	}
+
+	return &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		TLSHandshakeTimeout:   config.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: config.ResponseHeaderTimeout,
+		IdleConnTimeout:       config.IdleConnTimeout,
+		MaxIdleConns:          config.MaxIdleConns,
+		MaxIdleConnsPerHost:   config.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       config.MaxConnsPerHost,
+		DisableCompression:    config.DisableCompression,
+		ExpectContinueTimeout: time.Second,
+	}
+}
+
+// NewHTTPClient creates a new http.Client using a transport built from the given config
+func NewHTTPClient(config TransportConfig) *http.Client {
+	return &http.Client{
+		Transport: NewTransport(config),
+		Timeout:   config.Timeout,
+	}
+}
+
+// DefaultClient is the shared client used by requests without an explicit
+// client. Sharing it allows connections to be reused across requests.
+var DefaultClient Doer = NewHTTPClient(DefaultTransportConfig())
+
+// NewDownloadClient creates a client for downloads using a transport built
+// from the given config. Downloads may take longer than any request, so the
+// client has no overall timeout and is cancelled through the request context.
+func NewDownloadClient(config TransportConfig) *http.Client {
+	config.Timeout = 0
+	return NewHTTPClient(config)
+}
+
+// DefaultDownloadClient is the shared client used by downloads without an explicit client
+var DefaultDownloadClient Doer = NewDownloadClient(DefaultTransportConfig())
@@ -0,0 +1,223 @@
+package request_util
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token bucket limiter shared by all requests of the process.
+// It slows down when Jira pushes back (additive increase, multiplicative decrease)
+// and pauses all requests while a Retry-After delay is pending.
+type RateLimiter struct {
+	mu          sync.Mutex
+	rate        float64 // current tokens per second, <= 0 means unlimited
+	maxRate     float64
+	minRate     float64
+	burst       float64
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time
+	throttled   time.Duration
+}
+
+// NewRateLimiter creates a new limiter allowing rate requests per second with
+// the given burst. A rate <= 0 disables the limit, but throttling delays are still honored.
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rate:    rate,
+		maxRate: rate,
+		minRate: rate / 16,
+		burst:   float64(burst),
+		tokens:  float64(burst),
+		last:    time.Now(),
+	}
+}
+
+// DefaultRateLimiter is the limiter shared by all JiraAPI objects without an explicit limiter
+var DefaultRateLimiter = NewRateLimiter(10, 10)
+
+// SetLimit changes the maximum rate and the burst of the limiter, e.g. to
+// configure DefaultRateLimiter. A rate <= 0 disables the limit.
+func (l *RateLimiter) SetLimit(rate float64, burst int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if burst < 1 {
+		burst = 1
+	}
+	l.rate = rate
+	l.maxRate = rate
+	l.minRate = rate / 16
+	l.burst = float64(burst)
+	l.tokens = float64(burst)
+	l.last = time.Now()
+}
+
+// refill adds the tokens accumulated since the last call. The mutex must be held.
+func (l *RateLimiter) refill(now time.Time) {
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+}
+
+// Wait blocks until a request may be sent or the context is cancelled
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		var wait time.Duration
+		switch {
+		case now.Before(l.pausedUntil):
+			wait = l.pausedUntil.Sub(now)
+		case l.rate <= 0:
+			l.mu.Unlock()
+			return nil
+		default:
+			l.refill(now)
+			if l.tokens >= 1 {
+				l.tokens--
+				l.mu.Unlock()
+				return nil
+			}
+			wait = time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+		}
+		l.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Throttle pauses all requests for the given delay and halves the request rate
+func (l *RateLimiter) Throttle(delay time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	until := now.Add(delay)
+	if until.After(l.pausedUntil) {
+		// Only count the wall clock time the pause is extended by
+		start := l.pausedUntil
+		if start.Before(now) {
+			start = now
+		}
+		l.throttled += until.Sub(start)
+		l.pausedUntil = until
+	}
+
+	l.slowDown()
+}
+
+// SlowDown halves the request rate without pausing, e.g. when Jira reports
+// that the rate limit is nearly reached
+func (l *RateLimiter) SlowDown() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.slowDown()
+}
+
+// slowDown halves the rate down to the minimum rate. The mutex must be held.
+func (l *RateLimiter) slowDown() {
+	if l.maxRate <= 0 {
+		return
+	}
+	l.refill(time.Now())
+	l.rate /= 2
+	if l.rate < l.minRate {
+		l.rate = l.minRate
+	}
+}
+
+// Success slowly raises the request rate back to the configured maximum
+func (l *RateLimiter) Success() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.maxRate <= 0 || l.rate >= l.maxRate {
+		return
+	}
+	l.refill(time.Now())
+	l.rate += l.maxRate / 20
+	if l.rate > l.maxRate {
+		l.rate = l.maxRate
+	}
+}
+
+// Rate returns the current number of requests per second
+func (l *RateLimiter) Rate() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.rate
+}
+
+// ThrottledTime returns the total wall clock time requests were paused due to throttling
+func (l *RateLimiter) ThrottledTime() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.throttled
+}
+
+// IsThrottled reports whether the response signals that the request was rate limited
+func IsThrottled(resp *http.Response) bool {
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable
+}
+
+// IsNearRateLimit reports whether the X-RateLimit-* headers signal that the
+// rate limit is nearly reached
+func IsNearRateLimit(resp *http.Response) bool {
+	if resp.Header.Get("X-RateLimit-NearLimit") == "true" {
+		return true
+	}
+
+	remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return false
+	}
+	limit, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Limit"))
+	if err != nil || limit <= 0 {
+		return false
+	}
+	// Treat less than 10% of the remaining capacity as near the limit
+	return remaining*10 < limit
+}
+
+// RetryAfter returns the delay requested by the server using the Retry-After
+// header (seconds or HTTP date) or the X-RateLimit-Reset header (ISO 8601)
+func RetryAfter(resp *http.Response, now time.Time) (time.Duration, bool) {
+	if v := resp.Header.Get("Retry-After"); v != "" {
+		if seconds, err := strconv.Atoi(v); err == nil && seconds >= 0 {
+			return time.Duration(seconds) * time.Second, true
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return nonNegative(t.Sub(now)), true
+		}
+	}
+
+	if v := resp.Header.Get("X-RateLimit-Reset"); v != "" {
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			return nonNegative(t.Sub(now)), true
+		}
+	}
+
+	return 0, false
+}
+
+func nonNegative(d time.Duration) time.Duration {
+	if d < 0 {
+		return 0
+	}
+	return d
+}
@@ -3,14 +3,56 @@ package secrets
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
 )
 
+const (
+	// AuthModeBasic uses the username and API token (Jira Cloud)
+	AuthModeBasic = "basic"
+	// AuthModeBearer uses a personal access token (Jira Server / Data Center)
+	AuthModeBearer = "bearer"
+)
+
 // Secrets contains the Jira API credentials
 type Secrets struct {
 	Username string `json:"username"`
 	Token    string `json:"token"`
 	URL      string `json:"url"`
+	// AuthMode is either "basic" or "bearer". Defaults to "basic" if empty.
+	AuthMode string `json:"authMode,omitempty"`
+}
+
+// Validate checks that the auth mode is known and the required credentials are set
+func (s *Secrets) Validate() error {
+	switch s.AuthMode {
+	case "", AuthModeBasic:
+		if s.Username == "" {
+			return fmt.Errorf("missing username")
+		}
+	case AuthModeBearer:
+	default:
+		return fmt.Errorf("unknown auth mode %q", s.AuthMode)
+	}
+
+	if s.Token == "" {
+		return fmt.Errorf("missing token")
+	}
+
+	if s.URL == "" {
+		return fmt.Errorf("missing URL")
+	}
+
+	return nil
+}
+
+// SetAuth sets the authorization header of the request according to the auth mode
+func (s *Secrets) SetAuth(req *http.Request) {
+	if s.AuthMode == AuthModeBearer {
+		req.Header.Set("Authorization", "Bearer "+s.Token)
+		return
+	}
+	req.SetBasicAuth(s.Username, s.Token)
 }
 
 // FromFile reads the JSON file and populates the Secrets struct
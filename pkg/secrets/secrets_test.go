@@ -3,6 +3,7 @@ package secrets
 import (
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"path/filepath"
 	"testing"
@@ -68,3 +69,19 @@ func TestReadFromFile(t *testing.T) {
 
 	RemoveTestSecretsFile(f)
 }
+
+func TestSetAuth(t *testing.T) {
+	req, _ := http.NewRequest("GET", "https://testurl.atlassian.net", nil)
+
+	s := Secrets{Username: "testuser", Token: "testtoken"}
+	s.SetAuth(req)
+	if username, token, ok := req.BasicAuth(); !ok || username != "testuser" || token != "testtoken" {
+		t.Errorf("expected basic auth with testuser:testtoken, got %s", req.Header.Get("Authorization"))
+	}
+
+	s = Secrets{Token: "testtoken", AuthMode: AuthModeBearer}
+	s.SetAuth(req)
+	if got := req.Header.Get("Authorization"); got != "Bearer testtoken" {
+		t.Errorf("expected bearer auth, got %s", got)
+	}
+}